@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// buildTime might be set at build time:
+//
+//	go build -ldflags "-X github.com/LeKovr/go-kit/config.buildTime=$(date -u +%FT%TZ)"
+var buildTime string
+
+// ModuleInfo - модуль, использованный при сборке.
+type ModuleInfo struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	// Replaced - модуль заменен директивой replace, Version - версия замены (пустая для локального пути).
+	Replaced bool `json:"replaced,omitempty"`
+}
+
+// BuildInfo - сведения о сборке приложения из SetApplicationVersion и runtime/debug.ReadBuildInfo.
+type BuildInfo struct {
+	Application string       `json:"application"`
+	Version     string       `json:"version"`
+	Revision    string       `json:"revision,omitempty"`    // хэш коммита (vcs.revision)
+	Dirty       bool         `json:"dirty,omitempty"`       // есть незафиксированные изменения (vcs.modified)
+	CommitTime  string       `json:"commit_time,omitempty"` // время коммита (vcs.time)
+	BuildTime   string       `json:"build_time,omitempty"`  // время сборки (buildTime)
+	GoVersion   string       `json:"go_version,omitempty"`
+	Module      string       `json:"module,omitempty"` // путь main модуля
+	Deps        []ModuleInfo `json:"deps,omitempty"`
+}
+
+// ReadBuildInfo returns info about running binary.
+func ReadBuildInfo() BuildInfo {
+	rv := BuildInfo{Application: application, Version: version, BuildTime: buildTime}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return rv
+	}
+	rv.GoVersion = info.GoVersion
+	rv.Module = info.Main.Path
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rv.Revision = s.Value
+		case "vcs.modified":
+			rv.Dirty = s.Value == "true"
+		case "vcs.time":
+			rv.CommitTime = s.Value
+		}
+	}
+	for _, dep := range info.Deps {
+		rv.Deps = append(rv.Deps, moduleInfo(dep))
+	}
+	return rv
+}
+
+// moduleInfo returns dependency info keeping module path of replaced module.
+func moduleInfo(dep *debug.Module) ModuleInfo {
+	if dep.Replace != nil {
+		return ModuleInfo{Path: dep.Path, Version: dep.Replace.Version, Replaced: true}
+	}
+	return ModuleInfo{Path: dep.Path, Version: dep.Version}
+}
+
+// Attributes returns build info as key/value pairs
+// (e.g. for OpenTelemetry resource or log attributes).
+// Empty values are skipped.
+func (bi BuildInfo) Attributes() map[string]string {
+	rv := map[string]string{}
+	add := func(key, val string) {
+		if val != "" {
+			rv[key] = val
+		}
+	}
+	add("vcs.revision", bi.Revision)
+	if bi.Revision != "" {
+		add("vcs.modified", strconv.FormatBool(bi.Dirty))
+	}
+	add("vcs.time", bi.CommitTime)
+	add("build.time", bi.BuildTime)
+	add("go.version", bi.GoVersion)
+	return rv
+}
+
+// WriteBuildInfo пишет сведения о сборке в формате `text` или `json`.
+func WriteBuildInfo(w io.Writer, bi BuildInfo, format string) error {
+	switch format {
+	case "json":
+		return writeJSON(w, bi)
+	case "text":
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	ew := &errWriter{w: w}
+	ew.printf("%s %s\n", bi.Application, bi.Version)
+	line := func(name, val string) {
+		if val != "" {
+			ew.printf("%-12s %s\n", name+":", val)
+		}
+	}
+	rev := bi.Revision
+	if rev != "" && bi.Dirty {
+		rev += " (dirty)"
+	}
+	line("revision", rev)
+	line("commit time", bi.CommitTime)
+	line("build time", bi.BuildTime)
+	line("go", bi.GoVersion)
+	line("module", bi.Module)
+	if len(bi.Deps) > 0 {
+		ew.printf("deps:\n")
+		for _, dep := range bi.Deps {
+			ver := dep.Version
+			if dep.Replaced {
+				ver = strings.TrimSpace(ver + " (replaced)")
+			}
+			ew.printf("  %s %s\n", dep.Path, ver)
+		}
+	}
+	return ew.err
+}
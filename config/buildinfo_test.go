@@ -0,0 +1,82 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBuildInfo(t *testing.T) {
+	SetApplicationVersion("app", "v1.2.3")
+	bi := ReadBuildInfo()
+	assert.Equal(t, "app", bi.Application)
+	assert.Equal(t, "v1.2.3", bi.Version)
+	assert.Equal(t, runtime.Version(), bi.GoVersion)
+}
+
+func TestModuleInfo(t *testing.T) {
+	dep := &debug.Module{Path: "github.com/LeKovr/go-kit/config", Version: "v0.1.0", Replace: &debug.Module{Path: "../config"}}
+	assert.Equal(t, ModuleInfo{Path: "github.com/LeKovr/go-kit/config", Replaced: true}, moduleInfo(dep))
+
+	dep.Replace = &debug.Module{Path: "github.com/fork/config", Version: "v0.2.0"}
+	assert.Equal(t, ModuleInfo{Path: "github.com/LeKovr/go-kit/config", Version: "v0.2.0", Replaced: true}, moduleInfo(dep))
+
+	dep.Replace = nil
+	assert.Equal(t, ModuleInfo{Path: "github.com/LeKovr/go-kit/config", Version: "v0.1.0"}, moduleInfo(dep))
+}
+
+func TestWriteBuildInfo(t *testing.T) {
+	bi := BuildInfo{
+		Application: "app",
+		Version:     "v1.2.3",
+		Revision:    "abc123",
+		Dirty:       true,
+		BuildTime:   "2026-01-02T03:04:05Z",
+		GoVersion:   "go1.25.0",
+		Module:      "example.com/app",
+		Deps: []ModuleInfo{
+			{Path: "github.com/jessevdk/go-flags", Version: "v1.6.1"},
+			{Path: "github.com/LeKovr/go-kit/config", Replaced: true},
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, WriteBuildInfo(&buf, bi, "text"))
+	want := `app v1.2.3
+revision:    abc123 (dirty)
+build time:  2026-01-02T03:04:05Z
+go:          go1.25.0
+module:      example.com/app
+deps:
+  github.com/jessevdk/go-flags v1.6.1
+  github.com/LeKovr/go-kit/config (replaced)
+`
+	assert.Equal(t, want, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteBuildInfo(&buf, bi, "json"))
+	var got BuildInfo
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, bi, got)
+
+	assert.ErrorIs(t, WriteBuildInfo(&buf, bi, "xml"), ErrUnknownFormat)
+
+	assert.Equal(t, map[string]string{
+		"vcs.revision": "abc123",
+		"vcs.modified": "true",
+		"build.time":   "2026-01-02T03:04:05Z",
+		"go.version":   "go1.25.0",
+	}, bi.Attributes())
+}
+
+func TestVersionFormat(t *testing.T) {
+	var cfg struct {
+		EnableShowVersion
+	}
+	assert.ErrorIs(t, Open(&cfg, "--version_format=json"), ErrVersion)
+	assert.Error(t, Open(&cfg, "--version_format=yaml"))
+}
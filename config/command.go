@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// commandError returns go-flags like error for missing or unknown command.
+func commandError(p *flags.Parser, args []string) error {
+	var names []string
+	for _, c := range p.Commands() {
+		if !c.Hidden {
+			names = append(names, c.Name)
+		}
+	}
+	slices.Sort(names)
+	msg := "Please specify one command of: " + strings.Join(names, ", ")
+	if len(args) > 0 {
+		return &flags.Error{Type: flags.ErrUnknownCommand, Message: fmt.Sprintf("Unknown command `%s'. %s", args[0], msg)}
+	}
+	return &flags.Error{Type: flags.ErrCommandRequired, Message: msg}
+}
+
+// activeCommands returns names of chosen command and its parents.
+func activeCommands(p *flags.Parser) map[string]bool {
+	rv := map[string]bool{}
+	for c := p.Active; c != nil; c = c.Active {
+		rv[c.Name] = true
+	}
+	return rv
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ServeCommand struct {
+	Listen string `long:"listen" env:"LISTEN" default:":8080" validate:"hostport" description:"Addr to listen"`
+	run    func(args []string) error
+}
+
+func (cmd *ServeCommand) Execute(args []string) error {
+	return cmd.run(args)
+}
+
+type MigrateCommand struct {
+	DSN string `long:"dsn" env:"DSN" validate:"min=1" description:"Database DSN"`
+}
+
+type CommandConfig struct {
+	EnableShowVersion
+	EnableConfigDefGen
+	Debug   bool           `long:"debug" env:"DEBUG" description:"Debug mode"`
+	Serve   ServeCommand   `command:"serve" description:"Run server"`
+	Migrate MigrateCommand `command:"migrate" description:"Run migrations"`
+}
+
+func TestCommandExecute(t *testing.T) {
+	var got []string
+	cfg := CommandConfig{}
+	cfg.Serve.run = func(args []string) error {
+		got = args
+		assert.True(t, cfg.Debug, "root options are set before Execute")
+		return nil
+	}
+	// migrate DSN is required but not validated when serve is called
+	err := Open(&cfg, "serve", "--listen", "localhost:80", "--debug", "extra")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:80", cfg.Serve.Listen)
+	assert.Equal(t, []string{"extra"}, got)
+
+	errRun := errors.New("run failed")
+	cfg.Serve.run = func([]string) error { return errRun }
+	err = Open(&cfg, "serve")
+	assert.ErrorIs(t, err, errRun)
+}
+
+func TestCommandErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"required", []string{"--debug"}, "Please specify one command of: migrate, serve"},
+		{"unknown", []string{"run"}, "Unknown command `run'. Please specify one command of: migrate, serve"},
+		{"validate", []string{"migrate"}, "option --dsn: length must be >= 1 (got 0)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg CommandConfig
+			err := Open(&cfg, tt.args...)
+			require.ErrorAs(t, err, &ErrBadArgsContainer{})
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestCommandMixins(t *testing.T) {
+	var cfg CommandConfig
+	err := Open(&cfg, "--version")
+	assert.ErrorIs(t, err, ErrVersion)
+}
+
+func TestCommandDefs(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteConfig(&buf, CommandConfig{}, "mk"))
+	assert.Contains(t, buf.String(), "\n# Command serve: Run server\n\n# Command Options\n\n#- Addr to listen (string) [:8080]\nLISTEN ")
+
+	buf.Reset()
+	require.NoError(t, WriteConfig(&buf, CommandConfig{}, "md"))
+	assert.Contains(t, buf.String(), "\n## Command migrate\n\nRun migrations\n\n### Command Options\n")
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// CompleteCommand - скрытая команда, которую вызывают скрипты автодополнения:
+// `myapp __complete <words...>` печатает варианты для последнего слова.
+const CompleteCommand = "__complete"
+
+// EnableCompletion при включении в Config добавляет поддержку `--completion`.
+type EnableCompletion struct {
+	GoKitConfigCompletionOption string `description:"Print shell completion script and exit (default: '', means skip)" long:"completion" choice:"" choice:"bash" choice:"zsh" choice:"fish"`
+}
+
+// IsCompletionRequested доступен, если в структуру встроен `EnableCompletion`.
+type IsCompletionRequested interface {
+	GoKitConfigCompletionRequested() error
+}
+
+// Проверяем, что EnableCompletion implements IsCompletionRequested.
+var _ IsCompletionRequested = (*EnableCompletion)(nil)
+
+// GoKitConfigCompletionRequested prints completion script if shell is given.
+func (opt EnableCompletion) GoKitConfigCompletionRequested() error {
+	if opt.GoKitConfigCompletionOption != "" {
+		if err := WriteCompletion(os.Stdout, opt.GoKitConfigCompletionOption, application); err != nil {
+			return err
+		}
+		return ErrCompletion
+	}
+	return nil
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for %[1]s
+_%[2]s_complete() {
+    local IFS=$'\n'
+    COMPREPLY=($(%[1]s ` + CompleteCommand + ` "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _%[2]s_complete %[1]s
+`,
+	"zsh": `#compdef %[1]s
+_%[2]s() {
+    local -a opts
+    opts=("${(@f)$(%[1]s ` + CompleteCommand + ` "${(@)words[2,$CURRENT]}" 2>/dev/null)}")
+    compadd -- "${opts[@]}"
+}
+compdef _%[2]s %[1]s
+`,
+	"fish": `# fish completion for %[1]s
+function __%[2]s_complete
+    set -l args (commandline -opc) (commandline -ct)
+    %[1]s ` + CompleteCommand + ` $args[2..-1] 2>/dev/null
+end
+complete -c %[1]s -f -a '(__%[2]s_complete)'
+`,
+}
+
+var reNonIdent = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// WriteCompletion пишет скрипт автодополнения для shell (bash, zsh, fish) приложения name.
+func WriteCompletion(w io.Writer, shell, name string) error {
+	tmpl, ok := completionScripts[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q", shell)
+	}
+	_, err := fmt.Fprintf(w, tmpl, name, reNonIdent.ReplaceAllString(name, "_"))
+	return err
+}
+
+// completionOption - параметр для автодополнения.
+type completionOption struct {
+	Name    string
+	Choices []string
+	IsBool  bool
+}
+
+// completionLevel - параметры и команды одного уровня вложенности команд.
+type completionLevel struct {
+	options  []completionOption
+	commands map[string][]Def
+	names    []string
+}
+
+// newCompletionLevel собирает параметры и команды из defs.
+func newCompletionLevel(defs []Def) *completionLevel {
+	rv := &completionLevel{commands: map[string][]Def{}}
+	rv.add(defs, "")
+	return rv
+}
+
+func (l *completionLevel) add(defs []Def, prefix string) {
+	for _, def := range defs {
+		switch {
+		case def.IsCommand:
+			l.commands[def.Name] = def.Group.Items
+			l.names = append(l.names, def.Name)
+		case def.IsGroup:
+			np := prefix
+			if def.Name != "" {
+				np = prefix + def.Name + "."
+			}
+			l.add(def.Group.Items, np)
+		case def.Name != "":
+			l.options = append(l.options, completionOption{
+				Name:    "--" + prefix + def.Name,
+				Choices: def.Item.Options,
+				IsBool:  def.Item.Type == "bool",
+			})
+		}
+	}
+}
+
+func (l *completionLevel) option(name string) (completionOption, bool) {
+	for _, opt := range l.options {
+		if opt.Name == name {
+			return opt, true
+		}
+	}
+	return completionOption{}, false
+}
+
+// writeCandidates пишет варианты дополнения последнего из words для параметров cfg.
+// Слово "=" (bash разделяет по нему `--opt=value`) относится к предыдущему параметру.
+func writeCandidates(w io.Writer, cfg any, words []string) error {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	level := newCompletionLevel(FetchDefs(cfg))
+	cur, prev := words[len(words)-1], words[:len(words)-1]
+	for _, word := range prev {
+		if items, ok := level.commands[word]; ok {
+			// параметры родителя доступны и после имени команды
+			next := newCompletionLevel(items)
+			next.options = slices.Concat(level.options, next.options)
+			level = next
+		}
+	}
+	var rv []string
+	switch {
+	case cur == "=" && len(prev) > 0:
+		// bash: `--opt=`
+		rv = level.choices(prev[len(prev)-1], "", "")
+	case len(prev) > 1 && prev[len(prev)-1] == "=":
+		// bash: `--opt=va`
+		rv = level.choices(prev[len(prev)-2], cur, "")
+	case strings.HasPrefix(cur, "--") && strings.Contains(cur, "="):
+		// zsh, fish: `--opt=va`
+		name, val, _ := strings.Cut(cur, "=")
+		rv = level.choices(name, val, name+"=")
+	case strings.HasPrefix(cur, "-"):
+		for _, opt := range append(level.options, completionOption{Name: "--help", IsBool: true}) {
+			if strings.HasPrefix(opt.Name, cur) {
+				rv = append(rv, opt.Name)
+			}
+		}
+	default:
+		if len(prev) > 0 {
+			if opt, ok := level.option(prev[len(prev)-1]); ok && !opt.IsBool {
+				// значение параметра, заданного отдельным словом
+				rv = level.choices(opt.Name, cur, "")
+				break
+			}
+		}
+		for _, name := range level.names {
+			if strings.HasPrefix(name, cur) {
+				rv = append(rv, name)
+			}
+		}
+	}
+	for _, s := range rv {
+		if _, err := fmt.Fprintln(w, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// choices возвращает допустимые значения параметра name, начинающиеся с val.
+func (l *completionLevel) choices(name, val, prefix string) []string {
+	opt, ok := l.option(name)
+	if !ok {
+		return nil
+	}
+	var rv []string
+	for _, choice := range opt.Choices {
+		if choice != "" && strings.HasPrefix(choice, val) {
+			rv = append(rv, prefix+choice)
+		}
+	}
+	return rv
+}
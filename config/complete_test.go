@@ -0,0 +1,70 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type CompleteLog struct {
+	Debug  bool   `long:"debug" description:"Show debug info"`
+	Format string `long:"format" choice:"" choice:"text" choice:"json" description:"Output format"`
+}
+
+type CompleteServe struct {
+	Listen string `long:"listen" description:"Addr to listen"`
+}
+
+type CompleteConfig struct {
+	EnableCompletion
+	Log   CompleteLog   `group:"Logging" namespace:"log"`
+	Serve CompleteServe `command:"serve" description:"Run server"`
+	Stop  struct{}      `command:"stop" description:"Stop server"`
+}
+
+func TestCompletionCandidates(t *testing.T) {
+	tests := []struct {
+		name  string
+		words []string
+		want  string
+	}{
+		{"commands", []string{""}, "serve stop"},
+		{"command prefix", []string{"se"}, "serve"},
+		{"options", []string{"--lo"}, "--log.debug --log.format"},
+		{"command options", []string{"serve", "--"}, "--completion --log.debug --log.format --listen --help"},
+		{"choice zsh", []string{"--log.format=j"}, "--log.format=json"},
+		{"choice bash", []string{"--log.format", "=", ""}, "text json"},
+		{"choice bash prefix", []string{"--log.format", "=", "t"}, "text"},
+		{"choice word", []string{"--completion", "b"}, "bash"},
+		{"after bool", []string{"--log.debug", ""}, "serve stop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, writeCandidates(&buf, CompleteConfig{}, tt.words))
+			assert.Equal(t, tt.want, strings.Join(strings.Fields(buf.String()), " "))
+		})
+	}
+}
+
+func TestCompletionOpen(t *testing.T) {
+	var cfg CompleteConfig
+	err := Open(&cfg, CompleteCommand, "st")
+	assert.ErrorIs(t, err, ErrCompletion)
+
+	err = Open(&cfg, "--completion", "bash")
+	assert.ErrorIs(t, err, ErrCompletion)
+}
+
+func TestWriteCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var buf bytes.Buffer
+		require.NoError(t, WriteCompletion(&buf, shell, "my-app"))
+		assert.Contains(t, buf.String(), "my-app "+CompleteCommand, shell)
+		assert.Contains(t, buf.String(), "my_app", shell)
+	}
+	assert.Error(t, WriteCompletion(&bytes.Buffer{}, "tcsh", "app"))
+}
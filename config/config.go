@@ -2,7 +2,9 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
+	"os"
 
 	flags "github.com/jessevdk/go-flags"
 )
@@ -27,6 +29,12 @@ var (
 	ErrVersion = errors.New("version printed")
 	// ErrConfGen returned after config generation
 	ErrConfGen = errors.New("config printed")
+	// ErrCompletion returned after printing completion script or candidates
+	ErrCompletion = errors.New("completion printed")
+	// ErrExplain returned after printing config value sources
+	ErrExplain = errors.New("config explained")
+	// ErrConfigInit returned after config file creation by `--config_init`
+	ErrConfigInit = errors.New("config file created")
 )
 
 // ErrBadArgsContainer holds config parse error
@@ -39,42 +47,130 @@ func (e ErrBadArgsContainer) Error() string {
 	return e.err.Error()
 }
 
-// Open loads flags from args (if given) or command flags and ENV otherwise
+// Unwrap returns inner error
+func (e ErrBadArgsContainer) Unwrap() error {
+	return e.err
+}
+
+// Open loads flags from args (if given) or command flags and ENV otherwise.
+// Values are applied in order: defaults < config file < providers (see OpenWith) < ENV < flags.
+// If cfg has commands (fields with `command` tag), the chosen command is required
+// and its Execute (if command implements flags.Commander) is called after
+// validation and ProcessOptions, so Open returns its error.
 func Open(cfg any, args ...string) (err error) {
-	p := flags.NewParser(cfg, flags.Default) //  HelpFlag | PrintErrors | PassDoubleDash
 	if len(args) == 0 {
-		_, err = p.Parse()
+		return OpenWith(cfg)
+	}
+	return OpenWith(cfg, WithArgs(args...))
+}
+
+// OpenWith loads cfg like Open with given options, e.g.
+//
+//	err := config.OpenWith(&cfg, config.WithProvider(config.NewHTTPProvider(url)))
+func OpenWith(cfg any, opts ...OpenOption) error {
+	cmd, rest, err := parse(cfg, newOpenOptions(opts))
+	if err != nil || cmd == nil {
+		return err
+	}
+	return cmd.Execute(rest)
+}
+
+// parse loads cfg like Open but returns active command instead of calling it.
+func parse(cfg any, o openOptions) (cmd flags.Commander, rest []string, err error) {
+	p := newParser(cfg, flags.Default) //  HelpFlag | PrintErrors | PassDoubleDash
+	args := o.args
+	if args == nil {
+		args = os.Args[1:]
+	}
+	if _, ok := cfg.(IsCompletionRequested); ok && len(args) > 0 && args[0] == CompleteCommand {
+		if err = writeCandidates(os.Stdout, cfg, args[1:]); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrCompletion
+	}
+	if _, ok := cfg.(IsConfigInitRequested); ok && !o.reload {
+		// `--config_init` выполняем до разбора, т.к. обязательные параметры еще не заданы
+		if v, ok := preParse(cfg, args, o.env).(IsConfigInitRequested); ok {
+			if err = v.GoKitConfigInitRequested(cfg); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	hasCommands := len(p.Commands()) > 0
+	// Наличие команды проверяем после печатающих опций, чтобы `--version` и т.п. работали без нее
+	p.SubcommandsOptional = true
+	p.CommandHandler = func(c flags.Commander, a []string) error {
+		cmd, rest = c, a
+		return nil
+	}
+	var remote map[string]string
+	if !helpRequested(args) {
+		// недоступный провайдер не должен мешать `-h` и `--version`
+		if remote, err = fetchProviders(o.ctx, o.providers); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, ErrBadArgsContainer{err}
+		}
+	}
+	idx := optionIndex(p)
+	markDeprecated(idx)
+	defaults := tagDefaults(idx)
+	args = rewriteAliases(idx, args)
+	sources, err := preload(idx, cfg, args, remote, o)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, ErrBadArgsContainer{err}
+	}
+	if o.env != nil {
+		restore := hideEnv(idx)
+		_, err = p.ParseArgs(args)
+		restore()
 	} else {
 		_, err = p.ParseArgs(args)
 	}
 	if err != nil {
 		if e, ok := err.(*flags.Error); ok && e.Type == flags.ErrHelp {
-			return ErrHelpRequest
+			return nil, nil, ErrHelpRequest
+		}
+		return nil, nil, ErrBadArgsContainer{err}
+	}
+	warnDeprecated(idx, sources, o.lookupEnv)
+	if v, ok := cfg.(IsOriginsRecorded); ok {
+		if p, ok := cfg.(IsProfileRequested); ok {
+			profileDefaults(defaults, idx, p.GoKitConfigProfile())
 		}
-		return ErrBadArgsContainer{err}
+		v.GoKitConfigSetOrigins(origins(idx, defaults, sources, o.lookupEnv))
 	}
-	return ProcessOptions(cfg)
+	if !o.reload {
+		if err = processPrintOptions(cfg); err != nil {
+			return nil, nil, err
+		}
+	}
+	if hasCommands && p.Active == nil {
+		err = commandError(p, rest)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, ErrBadArgsContainer{err}
+	}
+	if err = validate(cfg, activeCommands(p)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, ErrBadArgsContainer{err}
+	}
+	if !o.reload {
+		// `--config_dump` и т.п. выполняются только при запуске и только для корректного конфига
+		if err = processSaveOptions(cfg); err != nil {
+			return nil, nil, err
+		}
+	}
+	return cmd, rest, nil
 }
 
-// Close runs exit after deferred cleanups have run
+// Close runs exit after deferred cleanups have run.
+// Exit code is selected by ExitCode, application errors are logged,
+// hooks added by OnExit are called before exitFunc.
 func Close(e error, exitFunc func(code int)) {
-	if e == nil {
-		exitFunc(ExitNormal)
-		return
-	}
-	code := ExitError
-	switch {
-	case errors.Is(e, ErrHelpRequest):
-		code = ExitHelp
-	case errors.Is(e, ErrVersion), errors.Is(e, ErrConfGen):
-		code = ExitNormal
-	case errors.Is(e, ErrPrinted):
-		// error was already printed
-	case errors.As(e, &ErrBadArgsContainer{}):
-		code = ExitBadArgs
-	default:
-		slog.Error("Application error", "err", e)
+	code, isAppError := exitCode(e)
+	if isAppError {
+		slog.Error("Application error", "err", e, "code", code)
 	}
-
+	runExitHooks(code, e)
 	exitFunc(code)
 }
@@ -1,12 +1,14 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type MyConfig struct {
@@ -125,3 +127,46 @@ func Example_versionRequested() {
 	// app version
 	// version printed
 }
+
+type PrefixGroup struct {
+	Listen string `long:"listen" env:"LISTEN" env-alias:"ADDR" default:":8080" description:"Addr"`
+}
+
+type PrefixConfig struct {
+	Level string      `long:"level" env:"LEVEL" default:"info" description:"Level"`
+	Srv   PrefixGroup `group:"Server" namespace:"srv" env-namespace:"SRV"`
+	Plain PrefixGroup `group:"Plain" namespace:"plain"`
+}
+
+func TestEnvPrefix(t *testing.T) {
+	assert.Equal(t, "MY_APP2", EnvPrefix("my-app2"))
+
+	SetEnvPrefix("MYAPP")
+	t.Cleanup(func() { SetEnvPrefix("") })
+
+	var cfg PrefixConfig
+	env := map[string]string{"MYAPP_LEVEL": "debug", "SRV_LISTEN": ":1", "MYAPP_LISTEN": ":2"}
+	require.NoError(t, OpenWith(&cfg, WithArgs(), WithEnv(env)))
+	assert.Equal(t, "debug", cfg.Level)
+	assert.Equal(t, ":8080", cfg.Srv.Listen, "ENV without prefix is ignored")
+	assert.Equal(t, ":2", cfg.Plain.Listen)
+
+	t.Setenv("MYAPP_SRV_LISTEN", ":3")
+	cfg = PrefixConfig{}
+	require.NoError(t, Open(&cfg, "--level", "warn"))
+	assert.Equal(t, ":3", cfg.Srv.Listen)
+
+	defs := FetchDefs(cfg)
+	assert.Equal(t, "MYAPP_LEVEL", defs[0].Env)
+	assert.Equal(t, "MYAPP_SRV", defs[1].Env)
+	assert.Equal(t, "LISTEN", defs[1].Group.Items[0].Env)
+	assert.Equal(t, "MYAPP_LISTEN", defs[2].Group.Items[0].Env)
+	assert.Equal(t, []string{"MYAPP_ADDR"}, defs[2].Group.Items[0].Item.EnvAliases)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteConfig(&buf, cfg, "env"))
+	assert.Contains(t, buf.String(), "\nMYAPP_SRV_LISTEN=:8080\n")
+	buf.Reset()
+	require.NoError(t, WriteConfig(&buf, cfg, "mk"))
+	assert.Contains(t, buf.String(), "MYAPP_SRV_LISTEN     ?= :8080\n")
+}
@@ -0,0 +1,134 @@
+// Package configtest содержит помощники для тестов приложений, использующих go-kit/config:
+// разбор конфигурации с заданным ENV, перехват stdout, проверку кода завершения
+// и сравнение описания конфигурации (`--config_gen`) с эталонными файлами.
+package configtest
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/LeKovr/go-kit/config"
+)
+
+// UpdateEnv - переменная окружения, при непустом значении которой эталонные файлы перезаписываются:
+// `CONFIGTEST_UPDATE=1 go test ./...`.
+const UpdateEnv = "CONFIGTEST_UPDATE"
+
+// isUpdate returns true if golden files must be written.
+// Besides UpdateEnv, `-update` flag is used if test package defines it.
+func isUpdate() bool {
+	if os.Getenv(UpdateEnv) != "" {
+		return true
+	}
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
+
+// stdoutMu serializes replacing of os.Stdout.
+var stdoutMu sync.Mutex
+
+// Result - результат Run.
+type Result struct {
+	Err    error  // ошибка config.OpenWith
+	Code   int    // код, с которым config.Close завершил бы работу
+	Stdout string // текст, напечатанный в os.Stdout
+}
+
+// Open вызывает config.OpenWith с аргументами args и значениями env вместо переменных окружения процесса,
+// поэтому допустим в параллельных тестах.
+func Open(cfg any, env map[string]string, args ...string) error {
+	return config.OpenWith(cfg, config.WithEnv(env), config.WithArgs(args...))
+}
+
+// Run вызывает Open, перехватывая stdout (вывод `--version`, `--config_gen`, `-h` и т.п.).
+// Т.к. перехватывается os.Stdout, Run не используется в параллельных тестах.
+func Run(t testing.TB, cfg any, env map[string]string, args ...string) Result {
+	t.Helper()
+	var rv Result
+	rv.Stdout = CaptureStdout(t, func() {
+		rv.Err = Open(cfg, env, args...)
+	})
+	rv.Code = ExitCode(rv.Err)
+	return rv
+}
+
+// CaptureStdout returns text printed to os.Stdout while fn is running.
+func CaptureStdout(t testing.TB, fn func()) (out string) {
+	t.Helper()
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("configtest: pipe: %v", err)
+	}
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(&buf, r)
+		close(done)
+	}()
+	stdout := os.Stdout
+	os.Stdout = w
+	// восстанавливаем os.Stdout и при t.FailNow в fn
+	defer func() {
+		os.Stdout = stdout
+		_ = w.Close()
+		<-done
+		_ = r.Close()
+		out = buf.String()
+	}()
+	fn()
+	return ""
+}
+
+// ExitCode returns exit code which config.Close would pass to exit func for err.
+// Unlike config.Close, it does not log err and does not call OnExit hooks.
+func ExitCode(err error) int {
+	return config.ExitCode(err)
+}
+
+// AssertExitCode checks exit code for err.
+func AssertExitCode(t testing.TB, err error, want int) {
+	t.Helper()
+	if got := ExitCode(err); got != want {
+		t.Errorf("exit code = %d, want %d (err: %v)", got, want, err)
+	}
+}
+
+// AssertGolden compares got with content of file path.
+// With UpdateEnv set (or `-update` flag defined by test package) file is written instead.
+func AssertGolden(t testing.TB, path string, got []byte) {
+	t.Helper()
+	if isUpdate() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("configtest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil { //nolint:gosec
+			t.Fatalf("configtest: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		t.Fatalf("configtest: %v (run tests with %s=1 to create)", err, UpdateEnv)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch (run tests with %s=1 to accept)\n--- got:\n%s\n--- want:\n%s", path, UpdateEnv, got, want)
+	}
+}
+
+// AssertConfigGen compares description of cfg in format (`md`, `mk` etc)
+// with golden file testdata/config_gen.<format>.golden.
+func AssertConfigGen(t testing.TB, cfg any, format string) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := config.WriteConfig(&buf, cfg, format); err != nil {
+		t.Fatalf("configtest: config_gen %s: %v", format, err)
+	}
+	AssertGolden(t, filepath.Join("testdata", "config_gen."+format+".golden"), buf.Bytes())
+}
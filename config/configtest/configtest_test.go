@@ -0,0 +1,100 @@
+package configtest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeKovr/go-kit/config"
+)
+
+// флаг, обычный для тестов с эталонными файлами, не конфликтует с configtest
+var _ = flag.Bool("update", false, "update golden files")
+
+type DBConfig struct {
+	Pool int    `long:"pool" env:"POOL" default:"1" description:"Pool size"`
+	DSN  string `long:"dsn" env:"DSN" description:"Database DSN"`
+}
+
+type Config struct {
+	config.EnableShowVersion
+	config.EnableConfigDefGen
+	Level string   `long:"level" env:"CONFIGTEST_LEVEL" default:"info" description:"Log level"`
+	DB    DBConfig `group:"DB Options" namespace:"db" env-namespace:"DB"`
+}
+
+func TestOpen(t *testing.T) {
+	t.Setenv("CONFIGTEST_LEVEL", "process")
+	var cfg Config
+	err := Open(&cfg, map[string]string{"CONFIGTEST_LEVEL": "debug", "DB_POOL": "3"}, "--db.dsn", "x")
+	require.NoError(t, err)
+	assert.Equal(t, "debug", cfg.Level)
+	assert.Equal(t, 3, cfg.DB.Pool)
+	assert.Equal(t, "x", cfg.DB.DSN)
+
+	cfg = Config{}
+	require.NoError(t, Open(&cfg, nil))
+	assert.Equal(t, "info", cfg.Level, "process ENV is not used")
+
+	cfg = Config{}
+	require.NoError(t, Open(&cfg, map[string]string{"CONFIGTEST_LEVEL": "debug"}, "--level", "warn"))
+	assert.Equal(t, "warn", cfg.Level, "flag overrides ENV")
+}
+
+func TestRun(t *testing.T) {
+	info := config.ReadBuildInfo()
+	t.Cleanup(func() {
+		config.SetApplicationVersion(info.Application, info.Version)
+	})
+	config.SetApplicationVersion("configtest", "v1.0.0")
+	var cfg Config
+	rv := Run(t, &cfg, nil, "--version")
+	assert.ErrorIs(t, rv.Err, config.ErrVersion)
+	assert.Equal(t, config.ExitNormal, rv.Code)
+	assert.Equal(t, "configtest v1.0.0\n", rv.Stdout)
+
+	cfg = Config{}
+	rv = Run(t, &cfg, nil, "-h")
+	assert.Equal(t, config.ExitHelp, rv.Code)
+	assert.Contains(t, rv.Stdout, "--db.pool=")
+
+	cfg = Config{}
+	rv = Run(t, &cfg, map[string]string{"DB_POOL": "many"})
+	assert.Equal(t, config.ExitBadArgs, rv.Code)
+}
+
+func TestCaptureStdout(t *testing.T) {
+	stdout := os.Stdout
+	out := CaptureStdout(t, func() {
+		_, _ = os.Stdout.WriteString(strings.Repeat("x", 100000))
+	})
+	assert.Len(t, out, 100000)
+	assert.Equal(t, stdout, os.Stdout)
+}
+
+func TestAssertExitCode(t *testing.T) {
+	AssertExitCode(t, nil, config.ExitNormal)
+	AssertExitCode(t, config.ErrHelpRequest, config.ExitHelp)
+	AssertExitCode(t, config.ErrConfGen, config.ExitNormal)
+}
+
+func TestAssertGoldenUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.golden")
+	t.Setenv(UpdateEnv, "1")
+	AssertGolden(t, path, []byte("data"))
+	t.Setenv(UpdateEnv, "")
+	AssertGolden(t, path, []byte("data"))
+}
+
+func TestAssertConfigGen(t *testing.T) {
+	for _, format := range []string{"md", "mk"} {
+		t.Run(format, func(t *testing.T) {
+			AssertConfigGen(t, Config{}, format)
+		})
+	}
+}
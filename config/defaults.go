@@ -4,6 +4,7 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 	"time"
 
@@ -25,14 +26,43 @@ import (
 // Non-zero fields in src always take priority over any default.
 // ErrRequired errors are silently ignored — Defaults only fills gaps, it
 // does not enforce field presence.
+//
+// Nil pointer groups are allocated, pointee of non-nil pointers, elements of
+// slices of structs and struct values of maps get their defaults too.
+// Fields whose defaults could not be applied are reported in returned error
+// (joined with errors.Join) along with the result filled as far as possible.
 func Defaults[T any](src T) (T, error) {
+	return ProfileDefaults(src, "")
+}
+
+// ProfileDefaults works like Defaults but values of `default-<profile>` tags
+// (see EnableProfile) take priority over `default` tags.
+func ProfileDefaults[T any](src T, profile string) (T, error) {
 	// Step 1 — let go-flags apply every `default` tag to a fresh zero struct.
 	// ParseArgs([]string{}) triggers the exact same internal setDefaults() path
 	// that go-flags uses during normal argument parsing, so all conversion
 	// rules (Unmarshaler, base, multi-default, duration strings, …) are
-	// handled identically.
+	// handled identically. go-flags also allocates nil pointer groups.
 	var withDefaults T
-	p := flags.NewParser(&withDefaults, flags.None)
+	if err := applyTagDefaults(&withDefaults, profile); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	// Step 2 — override defaults with whatever the caller already set in src.
+	// Any non-zero field in src wins over the default we just computed.
+	result := withDefaults
+	errs := overrideNonZero(reflect.ValueOf(&result).Elem(), reflect.ValueOf(src), "", profile)
+	return result, errors.Join(errs...)
+}
+
+// applyTagDefaults fills struct pointed by ptr with `default` (and `default-<profile>`) tag values.
+func applyTagDefaults(ptr any, profile string) error {
+	p := newParser(ptr, flags.None)
+	if profile != "" {
+		idx := optionIndex(p)
+		applyValues(idx, profileValues(idx, profile))
+	}
 	if _, err := p.ParseArgs([]string{}); err != nil {
 		var flagErr *flags.Error
 		// ErrRequired fires when a `required:"true"` field has no value.
@@ -41,50 +71,138 @@ func Defaults[T any](src T) (T, error) {
 		if !errors.As(err, &flagErr) ||
 			(flagErr.Type != flags.ErrRequired &&
 				flagErr.Type != flags.ErrCommandRequired) {
-			var zero T
-			return zero, err
+			return err
 		}
 	}
+	return nil
+}
 
-	// Step 2 — override defaults with whatever the caller already set in src.
-	// Any non-zero field in src wins over the default we just computed.
-	result := withDefaults
-	overrideNonZero(reflect.ValueOf(&result).Elem(), reflect.ValueOf(src))
-	return result, nil
+var timeType = reflect.TypeOf(time.Time{})
+
+// isGroupType returns true for struct types which may hold options.
+// time.Time and similar leaf structs must be copied whole.
+func isGroupType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// mergeDefaults returns copy of src (struct or pointer to struct) on top of
+// defaults of its type.
+func mergeDefaults(src reflect.Value, path, profile string) (reflect.Value, []error) {
+	t := src.Type()
+	if t.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			return src, nil
+		}
+		elem, errs := mergeDefaults(src.Elem(), path, profile)
+		rv := reflect.New(t.Elem())
+		rv.Elem().Set(elem)
+		return rv, errs
+	}
+	rv := reflect.New(t)
+	if err := applyTagDefaults(rv.Interface(), profile); err != nil {
+		return src, []error{fmt.Errorf("field %s: %w", path, err)}
+	}
+	return rv.Elem(), overrideNonZero(rv.Elem(), src, path+".", profile)
+}
+
+// hasGroupElem returns true if slice or map elements are structs or pointers to structs.
+func hasGroupElem(t reflect.Type) bool {
+	elem := t.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return isGroupType(elem)
 }
 
 // overrideNonZero copies non-zero fields from src on top of dst so that
 // explicit caller values always win over defaults.
-// It recurses into struct fields so nested groups are handled transparently.
-func overrideNonZero(dst, src reflect.Value) {
+// It recurses into struct fields, pointers to structs and structs in slices
+// and maps so nested groups are handled transparently.
+// Returned errors describe fields whose defaults could not be applied.
+func overrideNonZero(dst, src reflect.Value, path, profile string) []error {
+	var errs []error
 	t := src.Type()
-	for i := 0; i < t.NumField(); i++ {
+	for i := range t.NumField() {
+		field := t.Field(i)
 		sf := src.Field(i)
 		df := dst.Field(i)
+		name := path + field.Name
 
 		if !df.CanSet() {
+			if _, ok := field.Tag.Lookup("default"); ok {
+				errs = append(errs, fmt.Errorf("field %s: default of unexported field is not supported", name))
+			}
 			continue
 		}
 
 		switch sf.Kind() {
 		case reflect.Struct:
-			// time.Time and similar leaf structs must be copied whole, not
-			// recursed into field-by-field.
-			if sf.Type() == reflect.TypeOf(time.Time{}) {
+			if !isGroupType(sf.Type()) {
 				if !sf.IsZero() {
 					df.Set(sf)
 				}
 			} else {
-				overrideNonZero(df, sf)
+				errs = append(errs, overrideNonZero(df, sf, name+".", profile)...)
 			}
 		case reflect.Ptr:
-			if !sf.IsNil() {
+			switch {
+			case sf.IsNil():
+				// keep group allocated by go-flags (if any)
+			case !isGroupType(sf.Type().Elem()):
 				df.Set(sf)
+			case df.IsNil():
+				v, e := mergeDefaults(sf, name, profile)
+				df.Set(v)
+				errs = append(errs, e...)
+			default:
+				// defaults are in df, copy them before change
+				v := reflect.New(sf.Type().Elem())
+				v.Elem().Set(df.Elem())
+				errs = append(errs, overrideNonZero(v.Elem(), sf.Elem(), name+".", profile)...)
+				df.Set(v)
+			}
+		case reflect.Slice:
+			if sf.Len() == 0 {
+				if !sf.IsNil() {
+					df.Set(sf)
+				}
+				continue
+			}
+			if !hasGroupElem(sf.Type()) {
+				df.Set(sf)
+				continue
+			}
+			v := reflect.MakeSlice(sf.Type(), sf.Len(), sf.Len())
+			for j := range sf.Len() {
+				elem, e := mergeDefaults(sf.Index(j), fmt.Sprintf("%s[%d]", name, j), profile)
+				v.Index(j).Set(elem)
+				errs = append(errs, e...)
+			}
+			df.Set(v)
+		case reflect.Map:
+			if sf.Len() == 0 {
+				if !sf.IsNil() {
+					df.Set(sf)
+				}
+				continue
+			}
+			if !hasGroupElem(sf.Type()) {
+				df.Set(sf)
+				continue
+			}
+			v := reflect.MakeMapWithSize(sf.Type(), sf.Len())
+			iter := sf.MapRange()
+			for iter.Next() {
+				elem, e := mergeDefaults(iter.Value(), fmt.Sprintf("%s[%v]", name, iter.Key()), profile)
+				v.SetMapIndex(iter.Key(), elem)
+				errs = append(errs, e...)
 			}
+			df.Set(v)
 		default:
 			if !sf.IsZero() {
 				df.Set(sf)
 			}
 		}
 	}
+	return errs
 }
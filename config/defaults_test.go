@@ -4,6 +4,7 @@ package config
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -185,6 +186,89 @@ func TestDefaults_InvalidDefault_ReturnsError(t *testing.T) {
 	}
 }
 
+type ReplicaConfig struct {
+	Host string `long:"host" default:"replica"`
+	Port int    `long:"port" default:"5432"`
+}
+
+type ClusterConfig struct {
+	Primary  *DBConfig                 `group:"Primary" namespace:"primary"`
+	Backup   *DBConfig                 `group:"Backup" namespace:"backup"`
+	Replicas []ReplicaConfig           `no-flag:"true"`
+	Pool     []*ReplicaConfig          `no-flag:"true"`
+	Named    map[string]ReplicaConfig  `no-flag:"true"`
+	NamedPtr map[string]*ReplicaConfig `no-flag:"true"`
+}
+
+func TestDefaults_PointerGroups(t *testing.T) {
+	primary := &DBConfig{SSLMode: "require"}
+	cfg, err := Defaults(ClusterConfig{Primary: primary})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// nil pointer group is allocated
+	if cfg.Backup == nil {
+		t.Fatal("Backup: nil pointer group was not allocated")
+	}
+	assertInt(t, "Backup.MaxConns", int64(cfg.Backup.MaxConns), 10)
+
+	// non-nil pointer gets defaults for zero fields
+	assertStr(t, "Primary.SSLMode", cfg.Primary.SSLMode, "require")
+	assertInt(t, "Primary.MaxConns", int64(cfg.Primary.MaxConns), 10)
+	if primary.MaxConns != 0 || cfg.Primary == primary {
+		t.Error("original pointee was mutated or shared")
+	}
+}
+
+func TestDefaults_SlicesAndMaps(t *testing.T) {
+	src := ClusterConfig{
+		Replicas: []ReplicaConfig{{Host: "r1"}, {Port: 1}},
+		Pool:     []*ReplicaConfig{{Host: "p1"}, nil},
+		Named:    map[string]ReplicaConfig{"a": {Host: "a1"}},
+		NamedPtr: map[string]*ReplicaConfig{"b": {Port: 2}},
+	}
+	cfg, err := Defaults(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertStr(t, "Replicas[0].Host", cfg.Replicas[0].Host, "r1")
+	assertInt(t, "Replicas[0].Port", int64(cfg.Replicas[0].Port), 5432)
+	assertStr(t, "Replicas[1].Host", cfg.Replicas[1].Host, "replica")
+	assertInt(t, "Replicas[1].Port", int64(cfg.Replicas[1].Port), 1)
+	assertInt(t, "Pool[0].Port", int64(cfg.Pool[0].Port), 5432)
+	if cfg.Pool[1] != nil {
+		t.Error("Pool[1]: nil element must stay nil")
+	}
+	assertInt(t, "Named[a].Port", int64(cfg.Named["a"].Port), 5432)
+	assertStr(t, "NamedPtr[b].Host", cfg.NamedPtr["b"].Host, "replica")
+
+	// original values are not changed
+	assertInt(t, "src.Replicas[0].Port", int64(src.Replicas[0].Port), 0)
+	assertStr(t, "src.NamedPtr[b].Host", src.NamedPtr["b"].Host, "")
+}
+
+func TestDefaults_ReportsFailedFields(t *testing.T) {
+	type Bad struct {
+		N int `long:"n" default:"x"`
+	}
+	type S struct {
+		Items  []Bad  `no-flag:"true"`
+		hidden string `default:"secret"`
+		Name   string `long:"name" default:"ok"`
+	}
+	cfg, err := Defaults(S{Items: []Bad{{}}})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	for _, want := range []string{"field Items[0]:", "field hidden:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+	assertStr(t, "Name", cfg.Name, "ok")
+	_ = cfg.hidden
+}
+
 // Verify our Defaults signature is compatible with the flags.Unmarshaler
 // interface check at compile time.
 var _ flags.Unmarshaler = (*CustomType)(nil)
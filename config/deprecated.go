@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// Теги для переименования параметров без поломки существующих установок:
+//
+//	// старые имена `--srv.ip_header` и `SRV_IP_HEADER` продолжают работать
+//	IPHeader string `long:"real_ip_header" alias:"ip_header" env:"REAL_IP_HEADER" env-alias:"IP_HEADER"`
+//	// параметр будет удален
+//	NoCheck bool `long:"no-check" deprecated:"use --srv.tls.insecure"`
+//
+// Псевдонимы задаются без namespace группы (как `long` и `env`), несколько - через запятую.
+// При использовании псевдонима или параметра с тегом `deprecated` в лог пишется предупреждение.
+// Значение ENV с новым именем важнее значения со старым.
+const (
+	// DeprecatedMessage - текст предупреждения в логе.
+	DeprecatedMessage = "Deprecated config option"
+	// DeprecatedHint - пояснение для тега `deprecated` без текста.
+	DeprecatedHint = "will be removed"
+	// DeprecatedHelpFormat - пометка устаревшего параметра в описании (`-h`).
+	DeprecatedHelpFormat = "Deprecated: %s. "
+)
+
+// aliases returns values of comma separated tag.
+func aliases(tag string) []string {
+	var rv []string
+	for a := range strings.SplitSeq(tag, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			rv = append(rv, a)
+		}
+	}
+	return rv
+}
+
+// rewriteAliases replaces old flag names (`alias` tag) in args by actual ones.
+// Args after "--" are not changed.
+func rewriteAliases(idx map[string]*flags.Option, args []string) []string {
+	names := map[string]string{}
+	for name, opt := range idx {
+		prefix := strings.TrimSuffix(name, opt.LongName)
+		for _, a := range aliases(opt.Field().Tag.Get("alias")) {
+			names[prefix+a] = name
+		}
+	}
+	if len(names) == 0 {
+		return args
+	}
+	rv := slices.Clone(args)
+	for i, arg := range rv {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			continue
+		}
+		old, val, hasVal := strings.Cut(arg[2:], "=")
+		name, ok := names[old]
+		if !ok {
+			continue
+		}
+		slog.Warn(DeprecatedMessage, "flag", "--"+old, "hint", "use --"+name)
+		rv[i] = "--" + name
+		if hasVal {
+			rv[i] += "=" + val
+		}
+	}
+	return rv
+}
+
+// envAliases adds values of options from ENV with old names (`env-alias` tag)
+// if ENV with actual name is not set.
+func envAliases(idx map[string]*flags.Option, lookupEnv func(string) (string, bool),
+	values map[string][]string, sources map[string]string,
+) {
+	for name, opt := range idx {
+		key := opt.EnvKeyWithNamespace()
+		if key == "" {
+			continue
+		}
+		if _, ok := lookupEnv(key); ok {
+			continue
+		}
+		prefix := strings.TrimSuffix(key, opt.EnvDefaultKey)
+		for _, a := range aliases(opt.Field().Tag.Get("env-alias")) {
+			val, ok := lookupEnv(prefix + a)
+			if !ok {
+				continue
+			}
+			slog.Warn(DeprecatedMessage, "env", prefix+a, "hint", "use "+key)
+			if opt.EnvDefaultDelim != "" {
+				values[name] = strings.Split(val, opt.EnvDefaultDelim)
+			} else {
+				values[name] = []string{val}
+			}
+			sources[name] = SourceEnv + " " + prefix + a
+			break
+		}
+	}
+}
+
+// markDeprecated adds `deprecated` tag text to descriptions of options shown by `-h`.
+func markDeprecated(idx map[string]*flags.Option) {
+	for _, opt := range idx {
+		hint, ok := opt.Field().Tag.Lookup("deprecated")
+		if !ok {
+			continue
+		}
+		if hint == "" {
+			hint = DeprecatedHint
+		}
+		opt.Description = fmt.Sprintf(DeprecatedHelpFormat, strings.TrimSuffix(hint, ".")) + opt.Description
+	}
+}
+
+// warnDeprecated logs usage of options with `deprecated` tag.
+func warnDeprecated(idx map[string]*flags.Option, sources map[string]string, lookupEnv func(string) (string, bool)) {
+	for name, opt := range idx {
+		hint, ok := opt.Field().Tag.Lookup("deprecated")
+		if !ok {
+			continue
+		}
+		if hint == "" {
+			hint = DeprecatedHint
+		}
+		key := opt.EnvKeyWithNamespace()
+		_, isEnv := lookupEnv(key)
+		switch {
+		case opt.IsSet() && !opt.IsSetDefault():
+			slog.Warn(DeprecatedMessage, "flag", "--"+name, "hint", hint)
+		case key != "" && isEnv:
+			slog.Warn(DeprecatedMessage, "env", key, "hint", hint)
+		case sources[name] != "":
+			slog.Warn(DeprecatedMessage, "option", name, "source", sources[name], "hint", hint)
+		}
+	}
+}
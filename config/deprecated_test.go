@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	flags "github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type DeprecatedSrv struct {
+	IPHeader string `long:"real_ip_header" alias:"ip_header,iph" env:"REAL_IP_HEADER" env-alias:"IP_HEADER" default:"X-Real-IP" description:"Header for remote IP"`
+	NoCheck  bool   `long:"no-check" env:"NO_CHECK" deprecated:"use --srv.insecure" description:"Disable check"`
+	Old      string `long:"old" deprecated:""`
+}
+
+type DeprecatedConfig struct {
+	Srv DeprecatedSrv `group:"Server" namespace:"srv" env-namespace:"SRV"`
+}
+
+// captureLog returns default logger output written while fn is running.
+func captureLog(t *testing.T, fn func()) string {
+	t.Helper()
+	var buf bytes.Buffer
+	def := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(def)
+	fn()
+	return buf.String()
+}
+
+func TestAliases(t *testing.T) {
+	var cfg DeprecatedConfig
+	log := captureLog(t, func() {
+		require.NoError(t, OpenWith(&cfg, WithArgs("--srv.ip_header=X-Old"), WithEnv(nil)))
+	})
+	assert.Equal(t, "X-Old", cfg.Srv.IPHeader)
+	assert.Contains(t, log, `msg="Deprecated config option" flag=--srv.ip_header hint="use --srv.real_ip_header"`)
+
+	cfg = DeprecatedConfig{}
+	log = captureLog(t, func() {
+		require.NoError(t, OpenWith(&cfg, WithArgs("--srv.iph", "X-Iph"), WithEnv(nil)))
+	})
+	assert.Equal(t, "X-Iph", cfg.Srv.IPHeader)
+	assert.Contains(t, log, "flag=--srv.iph")
+
+	cfg = DeprecatedConfig{}
+	log = captureLog(t, func() {
+		require.NoError(t, OpenWith(&cfg, WithArgs(), WithEnv(map[string]string{"SRV_IP_HEADER": "X-Env"})))
+	})
+	assert.Equal(t, "X-Env", cfg.Srv.IPHeader)
+	assert.Contains(t, log, `env=SRV_IP_HEADER hint="use SRV_REAL_IP_HEADER"`)
+
+	cfg = DeprecatedConfig{}
+	env := map[string]string{"SRV_IP_HEADER": "X-Env", "SRV_REAL_IP_HEADER": "X-New"}
+	log = captureLog(t, func() {
+		require.NoError(t, OpenWith(&cfg, WithArgs(), WithEnv(env)))
+	})
+	assert.Equal(t, "X-New", cfg.Srv.IPHeader, "actual name has priority")
+	assert.Empty(t, log)
+
+	cfg = DeprecatedConfig{}
+	require.NoError(t, OpenWith(&cfg, WithArgs("--", "--srv.ip_header"), WithEnv(nil)))
+	assert.Equal(t, "X-Real-IP", cfg.Srv.IPHeader, "args after -- are not changed")
+}
+
+func TestWarnDeprecated(t *testing.T) {
+	var cfg DeprecatedConfig
+	log := captureLog(t, func() {
+		require.NoError(t, OpenWith(&cfg, WithArgs(), WithEnv(nil)))
+	})
+	assert.Empty(t, log, "no warnings for unused options")
+
+	log = captureLog(t, func() {
+		require.NoError(t, OpenWith(&cfg, WithArgs("--srv.no-check", "--srv.old=x"), WithEnv(nil)))
+	})
+	assert.True(t, cfg.Srv.NoCheck)
+	assert.Contains(t, log, `flag=--srv.no-check hint="use --srv.insecure"`)
+	assert.Contains(t, log, `flag=--srv.old hint="will be removed"`)
+
+	cfg = DeprecatedConfig{}
+	log = captureLog(t, func() {
+		require.NoError(t, OpenWith(&cfg, WithArgs(), WithEnv(map[string]string{"SRV_NO_CHECK": "true"})))
+	})
+	assert.Contains(t, log, "env=SRV_NO_CHECK")
+}
+
+func TestDeprecatedMD(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteConfig(&buf, DeprecatedConfig{}, "md"))
+	lines := strings.Split(buf.String(), "\n")
+	assert.Contains(t, lines, "| srv.real_ip_header   | SRV_REAL_IP_HEADER   | string | `X-Real-IP` | "+
+		"Header for remote IP (old names: `--srv.ip_header`, `--srv.iph`, `SRV_IP_HEADER`) |")
+	assert.Contains(t, lines, "| srv.no-check         | SRV_NO_CHECK         | bool | `false` | "+
+		"**Deprecated**: use --srv.insecure. Disable check |")
+
+	defs := FetchDefs(DeprecatedConfig{})
+	item := defs[0].Group.Items[2].Item
+	assert.Equal(t, DeprecatedHint, item.Deprecated)
+	assert.True(t, NewJSONSchema(defs, "").Properties["srv"].Properties["old"].Deprecated)
+}
+
+func TestDeprecatedHelp(t *testing.T) {
+	var cfg DeprecatedConfig
+	p := newParser(&cfg, flags.None)
+	idx := optionIndex(p)
+	markDeprecated(idx)
+	var buf bytes.Buffer
+	p.WriteHelp(&buf)
+	help := buf.String()
+	assert.Contains(t, help, "Deprecated: use --srv.insecure. Disable check")
+	assert.Contains(t, help, "Deprecated: will be removed.")
+	assert.NotContains(t, idx["srv.real_ip_header"].Description, "Deprecated")
+}
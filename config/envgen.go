@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// envItem - параметр конфигурации, который можно задать через ENV.
+type envItem struct {
+	Env   string // имя ENV с префиксами групп
+	Title string // название группы
+	Def   Def
+}
+
+// envItems возвращает параметры, имеющие ENV, в порядке вывода WriteConfigM.
+func envItems(defs []Def, envPrefix, title string) []envItem {
+	var rv []envItem
+	childs := []Def{}
+	for _, def := range defs {
+		if def.IsGroup || def.IsCommand {
+			childs = append(childs, def)
+			continue
+		}
+		if def.Env == "" {
+			continue
+		}
+		rv = append(rv, envItem{Env: envJoin(envPrefix, def.Env), Title: title, Def: def})
+	}
+	for _, def := range childs {
+		if def.IsCommand {
+			rv = append(rv, envItems(def.Group.Items, envPrefix, "Command "+def.Name)...)
+			continue
+		}
+		rv = append(rv, envItems(def.Group.Items, envJoin(envPrefix, def.Env), def.Description)...)
+	}
+	return rv
+}
+
+// envJoin объединяет префикс и имя ENV так же, как go-flags.
+func envJoin(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	if name == "" {
+		return prefix
+	}
+	return prefix + "_" + name
+}
+
+// envValue возвращает значение по умолчанию для файлов с ENV.
+func (item envItem) envValue() string {
+	def := item.Def.Item
+	switch {
+	case def.Secret:
+		return ""
+	case def.Default == "" && def.Type == "bool":
+		return "false"
+	}
+	return def.Default
+}
+
+// envComment возвращает описание параметра для комментария.
+func (item envItem) envComment() string {
+	def := item.Def.Item
+	typ := def.Type
+	if def.Options != nil {
+		typ = strings.Join(def.Options, ",")
+	}
+	rv := fmt.Sprintf("%s (%s)", item.Def.Description, typ)
+	if def.Secret {
+		rv += " [secret]"
+	}
+	return strings.TrimSpace(rv)
+}
+
+// WriteConfigEnv пишет параметры в формате .env файла.
+func WriteConfigEnv(w io.Writer, defs []Def) error {
+	ew := &errWriter{w: w}
+	var title string
+	for i, item := range envItems(defs, "", "Main Options") {
+		if i == 0 || item.Title != title {
+			title = item.Title
+			ew.printf("\n# %s\n", title)
+		}
+		ew.printf("\n# %s\n%s=%s\n", item.envComment(), item.Env, dotenvQuote(item.envValue()))
+	}
+	return ew.err
+}
+
+// WriteConfigCompose пишет параметры в формате блока `environment:` docker-compose.
+func WriteConfigCompose(w io.Writer, defs []Def) error {
+	ew := &errWriter{w: w}
+	ew.printf("environment:\n")
+	var title string
+	for i, item := range envItems(defs, "", "Main Options") {
+		if i == 0 || item.Title != title {
+			title = item.Title
+			ew.printf("  # %s\n", title)
+		}
+		ew.printf("  # %s\n  %s: %s\n", item.envComment(), item.Env, composeQuote(item.envValue()))
+	}
+	return ew.err
+}
+
+// WriteConfigK8s пишет ConfigMap и список `env:` контейнера Kubernetes.
+// Секреты не включаются в ConfigMap и берутся из Secret с тем же именем.
+func WriteConfigK8s(w io.Writer, defs []Def, name string) error {
+	ew := &errWriter{w: w}
+	items := envItems(defs, "", "Main Options")
+	ew.printf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: %s\ndata:\n", name)
+	for _, item := range items {
+		if item.Def.Item.Secret {
+			continue
+		}
+		ew.printf("  # %s\n  %s: %s\n", item.envComment(), item.Env, strconv.Quote(item.envValue()))
+	}
+	ew.printf("---\n")
+	ew.printf("# Container env, see spec.template.spec.containers[].env\n")
+	ew.printf("env:\n")
+	for _, item := range items {
+		ref := "configMapKeyRef"
+		if item.Def.Item.Secret {
+			ref = "secretKeyRef"
+		}
+		ew.printf("  - name: %s\n    valueFrom:\n      %s:\n        name: %s\n        key: %s\n", item.Env, ref, name, item.Env)
+	}
+	return ew.err
+}
+
+// composeQuote берет значение в кавычки и экранирует `$`, чтобы docker-compose не подставлял переменные.
+func composeQuote(s string) string {
+	return strings.ReplaceAll(strconv.Quote(s), "$", "$$")
+}
+
+// dotenvQuote берет значение в кавычки, если это нужно для .env.
+// Значения с `$` берутся в одинарные кавычки, т.к. в двойных docker-compose и godotenv подставляют переменные,
+// если это невозможно (есть `'` или перевод строки) - `$` экранируется.
+func dotenvQuote(s string) string {
+	if s == "" || !strings.ContainsAny(s, " \t\r\n\"'`#$\\=") {
+		return s
+	}
+	if !strings.Contains(s, "$") {
+		return strconv.Quote(s)
+	}
+	if !strings.ContainsAny(s, "'\r\n") {
+		return "'" + s + "'"
+	}
+	return strings.ReplaceAll(strconv.Quote(s), "$", `\$`)
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type EnvGenDB struct {
+	DSN  string `long:"dsn" env:"DSN" secret:"true" default:"postgres://u:p@localhost/db" description:"Database DSN"`
+	Pool int    `long:"pool" env:"POOL" default:"4" description:"Pool size"`
+}
+
+type EnvGenConfig struct {
+	Root  string   `long:"root" env:"ROOT" default:"/var/www" description:"Root dir"`
+	Debug bool     `long:"debug" env:"DEBUG" description:"Debug mode"`
+	Mode  string   `long:"mode" env:"MODE" choice:"a" choice:"b" default:"a b" description:"Mode"`
+	Price string   `long:"price" env:"PRICE" default:"$5" description:"Price"`
+	Flag  bool     `long:"flag" description:"No env"`
+	DB    EnvGenDB `group:"Database" namespace:"db" env-namespace:"DB"`
+}
+
+func ExampleWriteConfigEnv() {
+	_ = WriteConfigEnv(os.Stdout, FetchDefs(EnvGenConfig{}))
+	// Output:
+	//
+	// # Main Options
+	//
+	// # Root dir (string)
+	// ROOT=/var/www
+	//
+	// # Debug mode (bool)
+	// DEBUG=false
+	//
+	// # Mode (a,b)
+	// MODE="a b"
+	//
+	// # Price (string)
+	// PRICE='$5'
+	//
+	// # Database
+	//
+	// # Database DSN (string) [secret]
+	// DB_DSN=
+	//
+	// # Pool size (int)
+	// DB_POOL=4
+}
+
+func ExampleWriteConfigCompose() {
+	_ = WriteConfigCompose(os.Stdout, FetchDefs(EnvGenConfig{}))
+	// Output:
+	// environment:
+	//   # Main Options
+	//   # Root dir (string)
+	//   ROOT: "/var/www"
+	//   # Debug mode (bool)
+	//   DEBUG: "false"
+	//   # Mode (a,b)
+	//   MODE: "a b"
+	//   # Price (string)
+	//   PRICE: "$$5"
+	//   # Database
+	//   # Database DSN (string) [secret]
+	//   DB_DSN: ""
+	//   # Pool size (int)
+	//   DB_POOL: "4"
+}
+
+func ExampleWriteConfigK8s() {
+	_ = WriteConfigK8s(os.Stdout, FetchDefs(EnvGenConfig{}.DB), "app")
+	// Output:
+	// apiVersion: v1
+	// kind: ConfigMap
+	// metadata:
+	//   name: app
+	// data:
+	//   # Pool size (int)
+	//   POOL: "4"
+	// ---
+	// # Container env, see spec.template.spec.containers[].env
+	// env:
+	//   - name: DSN
+	//     valueFrom:
+	//       secretKeyRef:
+	//         name: app
+	//         key: DSN
+	//   - name: POOL
+	//     valueFrom:
+	//       configMapKeyRef:
+	//         name: app
+	//         key: POOL
+}
+
+func TestDotenvQuote(t *testing.T) {
+	for in, want := range map[string]string{
+		"":         "",
+		"plain":    "plain",
+		"a b":      `"a b"`,
+		"$5":       `'$5'`,
+		"p@$$w0rd": `'p@$$w0rd'`,
+		"it's $5":  `"it's \$5"`,
+		"$A\n$B":   `"\$A\n\$B"`,
+		`say "hi"`: `"say \"hi\""`,
+	} {
+		assert.Equal(t, want, dotenvQuote(in), in)
+	}
+}
@@ -11,6 +11,7 @@ type Config struct {
 	config.EnableShowVersion
 	config.EnableConfigDefGen
 	config.EnableConfigDump
+	config.EnableCompletion
 
 	// ... other config options ...
 }
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ExitCodeError - ошибка приложения со своим кодом завершения для Close.
+//
+//	return config.ExitCodeError{Code: 75, Err: err} // EX_TEMPFAIL
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+// Error returns inner Error() or exit code.
+func (e ExitCodeError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns inner error.
+func (e ExitCodeError) Unwrap() error {
+	return e.Err
+}
+
+type exitCodeMapping struct {
+	target error
+	code   int
+}
+
+var (
+	exitMu    sync.Mutex
+	exitCodes []exitCodeMapping
+	exitHooks []func(code int, err error)
+)
+
+// RegisterExitCode задает код завершения Close для ошибок, соответствующих target (errors.Is).
+// Проверка выполняется в порядке регистрации и до проверки ошибок пакета.
+func RegisterExitCode(target error, code int) {
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	exitCodes = append(exitCodes, exitCodeMapping{target: target, code: code})
+}
+
+// OnExit добавляет функцию, которую Close вызывает перед завершением работы
+// (например, для отправки метрик или отчета об ошибке).
+// Функции вызываются в обратном порядке, как defer.
+func OnExit(hook func(code int, err error)) {
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	exitHooks = append(exitHooks, hook)
+}
+
+// ExitCode returns exit code which Close uses for error e.
+func ExitCode(e error) int {
+	code, _ := exitCode(e)
+	return code
+}
+
+// exitCode returns exit code for e and true if e should be logged as application error.
+func exitCode(e error) (int, bool) {
+	if e == nil {
+		return ExitNormal, false
+	}
+	var exitErr ExitCodeError
+	if errors.As(e, &exitErr) {
+		return exitErr.Code, exitErr.Code != ExitNormal
+	}
+	exitMu.Lock()
+	mappings := slices.Clone(exitCodes)
+	exitMu.Unlock()
+	for _, m := range mappings {
+		if errors.Is(e, m.target) {
+			return m.code, m.code != ExitNormal
+		}
+	}
+	switch {
+	case errors.Is(e, ErrHelpRequest):
+		return ExitHelp, false
+	case errors.Is(e, ErrVersion), errors.Is(e, ErrConfGen), errors.Is(e, ErrCompletion),
+		errors.Is(e, ErrExplain), errors.Is(e, ErrConfigInit):
+		return ExitNormal, false
+	case errors.Is(e, ErrPrinted):
+		// error was already printed
+		return ExitError, false
+	case errors.As(e, &ErrBadArgsContainer{}):
+		return ExitBadArgs, false
+	}
+	return ExitError, true
+}
+
+// runExitHooks calls OnExit hooks in reverse order.
+func runExitHooks(code int, e error) {
+	exitMu.Lock()
+	hooks := slices.Clone(exitHooks)
+	exitMu.Unlock()
+	for _, hook := range slices.Backward(hooks) {
+		hook(code, e)
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// resetExit restores exit registry after test.
+func resetExit(t *testing.T) {
+	t.Helper()
+	codes, hooks := exitCodes, exitHooks
+	t.Cleanup(func() {
+		exitCodes, exitHooks = codes, hooks
+	})
+}
+
+func TestExitCode(t *testing.T) {
+	resetExit(t)
+	errTemp := errors.New("temporary failure")
+	errHelp := errors.New("custom help")
+	RegisterExitCode(errTemp, 75)
+	RegisterExitCode(errHelp, ExitNormal)
+	RegisterExitCode(ErrHelpRequest, 64)
+
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"Nil", nil, ExitNormal},
+		{"Registered", fmt.Errorf("fetch: %w", errTemp), 75},
+		{"Registered normal", errHelp, ExitNormal},
+		{"Overrides package error", ErrHelpRequest, 64},
+		{"Package error", ErrBadArgsContainer{errTemp}, 75},
+		{"Typed", ExitCodeError{Code: 69, Err: errTemp}, 69},
+		{"Wrapped typed", fmt.Errorf("run: %w", ExitCodeError{Code: 70}), 70},
+		{"Unknown", errors.New("unknown"), ExitError},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, ExitCode(tt.err), tt.name)
+	}
+	assert.Equal(t, "exit code 70", ExitCodeError{Code: 70}.Error())
+}
+
+func TestOnExit(t *testing.T) {
+	resetExit(t)
+	var calls []string
+	OnExit(func(code int, err error) { calls = append(calls, fmt.Sprintf("first %d %v", code, err)) })
+	OnExit(func(code int, _ error) { calls = append(calls, fmt.Sprintf("second %d", code)) })
+
+	var exitCode int
+	Close(ExitCodeError{Code: 75, Err: errors.New("busy")}, func(code int) {
+		calls = append(calls, "exit")
+		exitCode = code
+	})
+	assert.Equal(t, 75, exitCode)
+	assert.Equal(t, []string{"second 75", "first 75 busy", "exit"}, calls)
+}
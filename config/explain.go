@@ -0,0 +1,234 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// Источники значения параметра (Origin.Source). Для ENV и файлов к источнику
+// добавляется имя переменной или файла, например `env DB_POOL`, `file app.yaml`.
+const (
+	SourceUnset    = "unset"
+	SourceDefault  = "default"
+	SourceFlag     = "flag"
+	SourceEnv      = "env"
+	SourceProvider = "provider"
+	SourceProfile  = "profile"
+)
+
+// Origin - значение параметра и его источник.
+type Origin struct {
+	Name    string `json:"name"`              // имя параметра с namespace
+	Value   string `json:"value"`             // итоговое значение
+	Source  string `json:"source"`            // источник значения
+	Default string `json:"default,omitempty"` // значение из тега `default`
+}
+
+// IsOriginsRecorded доступен, если в структуру встроен `EnableConfigExplain`.
+// config.Open передает в него источники значений всех параметров.
+type IsOriginsRecorded interface {
+	GoKitConfigSetOrigins(origins []Origin)
+}
+
+// EnableConfigExplain при включении в Config добавляет поддержку `--config_explain`
+// и сохраняет источники значений параметров.
+type EnableConfigExplain struct {
+	GoKitConfigExplainOption bool `description:"Show config values with their sources and exit" long:"config_explain"`
+	origins                  []Origin
+}
+
+// Проверяем, что EnableConfigExplain implements IsOriginsRecorded.
+var _ IsOriginsRecorded = (*EnableConfigExplain)(nil)
+
+// IsExplainRequested доступен, если в структуру встроен `EnableConfigExplain`.
+type IsExplainRequested interface {
+	GoKitConfigExplainRequested(cfg any) error
+}
+
+// Проверяем, что EnableConfigExplain implements IsExplainRequested.
+var _ IsExplainRequested = (*EnableConfigExplain)(nil)
+
+// GoKitConfigSetOrigins saves origins.
+func (opt *EnableConfigExplain) GoKitConfigSetOrigins(origins []Origin) {
+	opt.origins = origins
+}
+
+// Origins returns sources of config values recorded by config.Open.
+func (opt EnableConfigExplain) Origins() []Origin {
+	return slices.Clone(opt.origins)
+}
+
+// GoKitConfigExplainRequested prints origins table if requested.
+func (opt EnableConfigExplain) GoKitConfigExplainRequested(cfg any) error {
+	if !opt.GoKitConfigExplainOption {
+		return nil
+	}
+	if err := WriteExplain(os.Stdout, FetchDefs(cfg), opt.origins); err != nil {
+		return err
+	}
+	return ErrExplain
+}
+
+// WriteExplain пишет таблицу параметров с итоговым значением, источником и значением по умолчанию
+// в порядке defs.
+func WriteExplain(w io.Writer, defs []Def, origins []Origin) error {
+	byName := map[string]Origin{}
+	for _, o := range origins {
+		byName[o.Name] = o
+	}
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	ew := &errWriter{w: tw}
+	ew.printf("FIELD\tVALUE\tSOURCE\tDEFAULT\n")
+	var walk func(defs []Def, prefix string)
+	walk = func(defs []Def, prefix string) {
+		for _, def := range defs {
+			switch {
+			case def.IsCommand:
+				walk(def.Group.Items, prefix)
+			case def.IsGroup:
+				np := prefix
+				if def.Name != "" {
+					np = prefix + def.Name + "."
+				}
+				walk(def.Group.Items, np)
+			case def.Name != "":
+				o, ok := byName[prefix+def.Name]
+				if !ok {
+					continue
+				}
+				ew.printf("%s\t%s\t%s\t%s\n", o.Name, o.Value, o.Source, o.Default)
+			}
+		}
+	}
+	walk(defs, "")
+	if ew.err != nil {
+		return ew.err
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	// убираем выравнивание пустой последней колонки
+	ew = &errWriter{w: w}
+	for line := range strings.Lines(buf.String()) {
+		ew.printf("%s\n", strings.TrimRight(line, " \n"))
+	}
+	return ew.err
+}
+
+// tagDefaults returns option defaults from struct tags.
+func tagDefaults(idx map[string]*flags.Option) map[string]string {
+	rv := make(map[string]string, len(idx))
+	for name, opt := range idx {
+		rv[name] = strings.Join(opt.Default, ",")
+	}
+	return rv
+}
+
+// profileDefaults replaces tag defaults with values of `default-<profile>` tags.
+func profileDefaults(defaults map[string]string, idx map[string]*flags.Option, profile string) {
+	for name, val := range profileValues(idx, profile) {
+		defaults[name] = strings.Join(val, ",")
+	}
+}
+
+// origins returns sources of parsed option values.
+// Preloaded values (sources) have lower priority than ENV and flags.
+func origins(idx map[string]*flags.Option, defaults, sources map[string]string, lookupEnv func(string) (string, bool)) []Origin {
+	rv := make([]Origin, 0, len(idx))
+	for name, opt := range idx {
+		o := Origin{Name: name, Default: defaults[name], Value: fmt.Sprint(opt.Value())}
+		key := opt.EnvKeyWithNamespace()
+		_, isEnv := lookupEnv(key)
+		switch {
+		case opt.IsSet() && !opt.IsSetDefault():
+			o.Source = SourceFlag
+		case key != "" && isEnv:
+			o.Source = SourceEnv + " " + key
+		case sources[name] != "":
+			o.Source = sources[name]
+		case o.Default != "":
+			o.Source = SourceDefault
+		default:
+			o.Source = SourceUnset
+		}
+		if isSecret(opt.Field().Tag) {
+			if o.Value != "" {
+				o.Value = SecretMask
+			}
+			if o.Default != "" {
+				o.Default = SecretMask
+			}
+		}
+		rv = append(rv, o)
+	}
+	slices.SortFunc(rv, func(a, b Origin) int { return strings.Compare(a.Name, b.Name) })
+	return rv
+}
+
+// Change - параметр, значение которого различается в сравниваемых конфигурациях.
+type Change struct {
+	Name string `json:"name"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+// Diff returns fields of config structs a and b which have different values.
+// Fields are named like options (`db.pool`), fields without `long` tag by Go name.
+// Values of secret fields are masked.
+func Diff[T any](a, b T) []Change {
+	av, bv := reflect.Indirect(reflect.ValueOf(&a).Elem()), reflect.Indirect(reflect.ValueOf(&b).Elem())
+	if !av.IsValid() || !bv.IsValid() || av.Kind() != reflect.Struct {
+		return nil
+	}
+	return diffStruct(av, bv, "")
+}
+
+func diffStruct(a, b reflect.Value, prefix string) []Change {
+	var rv []Change
+	t := a.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		af, bf := a.Field(i), b.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Tag.Get("long") == "" {
+			rv = append(rv, diffStruct(af, bf, groupPrefix(field, prefix))...)
+			continue
+		}
+		if !field.IsExported() || reflect.DeepEqual(af.Interface(), bf.Interface()) {
+			continue
+		}
+		ch := Change{Name: prefix + optionName(field), Old: af.Interface(), New: bf.Interface()}
+		if isSecret(field.Tag) {
+			ch.Old, ch.New = maskedValue(af).Interface(), maskedValue(bf).Interface()
+		}
+		rv = append(rv, ch)
+	}
+	return rv
+}
+
+// groupPrefix returns name prefix for fields of nested struct.
+func groupPrefix(field reflect.StructField, prefix string) string {
+	if ns := field.Tag.Get("namespace"); ns != "" && field.Tag.Get("group") != "" {
+		return prefix + ns + "."
+	}
+	return prefix
+}
+
+// optionName returns option long name or field name.
+func optionName(field reflect.StructField) string {
+	if name := field.Tag.Get("long"); name != "" {
+		return name
+	}
+	return field.Name
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ExplainDB struct {
+	Pool     int    `long:"pool" env:"EXPLAIN_POOL" default:"1"`
+	Password string `long:"password" env:"EXPLAIN_PASSWORD" default:"def" secret:"true"`
+}
+
+type ExplainConfig struct {
+	EnableConfigFile
+	EnableConfigExplain
+	Root  string        `long:"root" default:"/var/www"`
+	Name  string        `long:"name"`
+	Level string        `long:"level" default:"info"`
+	Grace time.Duration `long:"grace" default:"10s"`
+	DB    ExplainDB     `group:"DB" namespace:"db" env-namespace:"DB"`
+}
+
+func TestOrigins(t *testing.T) {
+	file := writeFile(t, "cfg.yaml", "level: debug\n")
+	t.Setenv("DB_EXPLAIN_POOL", "5")
+	t.Setenv("DB_EXPLAIN_PASSWORD", "pass")
+
+	var cfg ExplainConfig
+	require.NoError(t, Open(&cfg, "--config", file, "--grace", "1s"))
+
+	got := map[string]Origin{}
+	for _, o := range cfg.Origins() {
+		got[o.Name] = o
+	}
+	assert.Equal(t, Origin{Name: "root", Value: "/var/www", Source: SourceDefault, Default: "/var/www"}, got["root"])
+	assert.Equal(t, Origin{Name: "name", Value: "", Source: SourceUnset}, got["name"])
+	assert.Equal(t, Origin{Name: "level", Value: "debug", Source: "file " + file, Default: "info"}, got["level"])
+	assert.Equal(t, Origin{Name: "grace", Value: "1s", Source: SourceFlag, Default: "10s"}, got["grace"])
+	assert.Equal(t, Origin{Name: "db.pool", Value: "5", Source: "env DB_EXPLAIN_POOL", Default: "1"}, got["db.pool"])
+	assert.Equal(t, Origin{Name: "db.password", Value: SecretMask, Source: "env DB_EXPLAIN_PASSWORD", Default: SecretMask}, got["db.password"])
+}
+
+func TestWriteExplain(t *testing.T) {
+	var cfg ExplainConfig
+	require.NoError(t, Open(&cfg, "--name", "app"))
+	var buf bytes.Buffer
+	require.NoError(t, WriteExplain(&buf, FetchDefs(cfg), cfg.Origins()))
+	want := `FIELD           VALUE     SOURCE   DEFAULT
+config                    unset
+config_explain  false     unset
+root            /var/www  default  /var/www
+name            app       flag
+level           info      default  info
+grace           10s       default  10s
+db.pool         1         default  1
+db.password     ******    default  ******
+`
+	assert.Equal(t, want, buf.String())
+
+	cfg = ExplainConfig{}
+	err := Open(&cfg, "--config_explain")
+	assert.ErrorIs(t, err, ErrExplain)
+}
+
+func TestDiff(t *testing.T) {
+	a := ExplainConfig{Root: "/a", DB: ExplainDB{Pool: 1, Password: "x"}}
+	b := a
+	assert.Empty(t, Diff(a, b))
+
+	b.Root = "/b"
+	b.DB.Pool = 2
+	b.DB.Password = "y"
+	want := []Change{
+		{Name: "root", Old: "/a", New: "/b"},
+		{Name: "db.pool", Old: 1, New: 2},
+		{Name: "db.password", Old: SecretMask, New: SecretMask},
+	}
+	assert.Equal(t, want, Diff(a, b))
+	assert.Equal(t, want, Diff(&a, &b))
+	assert.Nil(t, Diff[*ExplainConfig](nil, &b))
+}
@@ -5,8 +5,9 @@ package config
 // https://chat.deepseek.com/a/chat/s/4d8221f2-fd41-4ad7-b1e6-d52ca5887fb5
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"reflect"
 	"regexp"
 	"strings"
@@ -22,6 +23,14 @@ type ItemDef struct {
 	Type    string   `json:"type"`
 	Default string   `json:"default,omitempty"`
 	Options []string `json:"options,omitempty"`
+	Secret  bool     `json:"secret,omitempty"`
+	// Deprecated - текст тега `deprecated` (для тега без текста - DeprecatedHint).
+	Deprecated string `json:"deprecated,omitempty"`
+	// Aliases - старые имена параметра (тег `alias`), EnvAliases - старые имена ENV (тег `env-alias`).
+	Aliases    []string `json:"aliases,omitempty"`
+	EnvAliases []string `json:"env_aliases,omitempty"`
+	// Profiles - значения по умолчанию профилей (теги `default-<profile>`).
+	Profiles map[string]string `json:"profiles,omitempty"`
 }
 
 // Def - атрибуты группы/параметра конфигурации.
@@ -30,6 +39,7 @@ type Def struct {
 	Env         string    `json:"env,omitempty"`
 	Description string    `json:"description,omitempty"`
 	IsGroup     bool      `json:"is_group,omitempty"`
+	IsCommand   bool      `json:"is_command,omitempty"`
 	Group       *GroupDef `json:"group,omitempty"`
 	Item        *ItemDef  `json:"item,omitempty"`
 }
@@ -46,40 +56,56 @@ var (
 	HeaderFormatMD = "\n### %s%s\n\n"
 	// TableHeaderMD - шапка таблицы группы параметров для Markdown.
 	TableHeaderMD = `| Name | ENV | Type | Default | Description |` + "\n|------|-----|------|---------|-------------|"
+
+	// CommandFormatMk - формат строки названия команды для Makefile.
+	CommandFormatMk = "\n# Command %s: %s\n"
+	// CommandFormatMD - формат заголовка команды для Markdown.
+	CommandFormatMD = "\n## Command %s\n\n%s\n"
+	// CommandTitle - название группы параметров команды.
+	CommandTitle = "Command Options"
+	// DeprecatedFormat - пометка устаревшего параметра в описании (Markdown).
+	DeprecatedFormat = "**Deprecated**: %s. "
+	// AliasesFormat - список старых имен параметра в описании (Markdown).
+	AliasesFormat = " (old names: %s)"
 )
 
 // PrintConfig fetches config tags from obj struct and prints them in given format.
-func PrintConfig(obj any, format string) {
+func PrintConfig(obj any, format string) error {
+	return WriteConfig(os.Stdout, obj, format)
+}
+
+// WriteConfig fetches config tags from obj struct and writes them in given format.
+func WriteConfig(w io.Writer, obj any, format string) error {
+	r, ok := lookupRenderer(format)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
 	defs := FetchDefs(obj)
-	// .md .mk .json
 	if defs == nil {
-		return
+		return nil
 	}
-	switch format {
-	case "json":
-		val, err := json.MarshalIndent(defs, "", "    ")
-		if err != nil {
-			//fmt.Errorf("failed to marshal JSON: %w", err)
-		}
-		fmt.Println(string(val))
-	case "md":
-		PrintConfigM(defs, false, "", "", "Main Options")
-	case "mk":
-		PrintConfigM(defs, true, "", "", "Main Options")
+	if v, ok := obj.(IsProfileRequested); ok {
+		// значения по умолчанию выбранного профиля
+		defs = profileDefs(defs, v.GoKitConfigProfile())
 	}
-
+	return r.Render(w, defs)
 }
 
 // PrintConfigM выводит конфиг в формате Makefile (onlyEnv) или MarkDown.
-func PrintConfigM(defs []Def, onlyEnv bool, namePrefix, envPrefix, title string) {
+func PrintConfigM(defs []Def, onlyEnv bool, namePrefix, envPrefix, title string) error {
+	return WriteConfigM(os.Stdout, defs, onlyEnv, namePrefix, envPrefix, title)
+}
 
+// WriteConfigM пишет конфиг в формате Makefile (onlyEnv) или MarkDown.
+func WriteConfigM(w io.Writer, defs []Def, onlyEnv bool, namePrefix, envPrefix, title string) error {
+	ew := &errWriter{w: w}
 	var fieldsFound bool
 	childs := []Def{}
 	for _, def := range defs {
-		if onlyEnv && def.Env == "" {
+		if onlyEnv && def.Env == "" && !def.IsCommand {
 			continue
 		}
-		if def.IsGroup {
+		if def.IsGroup || def.IsCommand {
 			// Вложенные группы подключим позже
 			childs = append(childs, def)
 			continue
@@ -92,10 +118,10 @@ func PrintConfigM(defs []Def, onlyEnv bool, namePrefix, envPrefix, title string)
 				if np != "" {
 					np = " {#" + np + "}"
 				}
-				fmt.Printf(HeaderFormatMD, title, np)
-				fmt.Println(TableHeaderMD)
+				ew.printf(HeaderFormatMD, title, np)
+				ew.printf("%s\n", TableHeaderMD)
 			} else {
-				fmt.Printf(HeaderFormatMk, title)
+				ew.printf(HeaderFormatMk, title)
 			}
 			if namePrefix != "" {
 				namePrefix = namePrefix + "."
@@ -115,12 +141,16 @@ func PrintConfigM(defs []Def, onlyEnv bool, namePrefix, envPrefix, title string)
 		}
 		n := namePrefix + def.Name
 		e := envPrefix + def.Env
+		if onlyEnv && def.Item.Secret {
+			// маску секрета не используем как значение
+			d = ""
+		}
 		dLabel := d
 		if onlyEnv {
 			if dLabel != "" {
 				dLabel = " " + dLabel
 			}
-			fmt.Printf(LineFormatMk, def.Description, typ, d, e, dLabel)
+			ew.printf(LineFormatMk, def.Description, typ, d, e, dLabel)
 		} else {
 			if d != "" {
 				// TODO: strings.ReplaceAll(d, "`", "\`")
@@ -130,19 +160,90 @@ func PrintConfigM(defs []Def, onlyEnv bool, namePrefix, envPrefix, title string)
 				e = "-"
 			}
 			de := strings.ReplaceAll(d, "\n", `\n`)
-			fmt.Printf(LineFormatMD, n, e, typ, de, def.Description)
+			desc := def.Description
+			if def.Item.Deprecated != "" {
+				desc = fmt.Sprintf(DeprecatedFormat, strings.TrimSuffix(def.Item.Deprecated, ".")) + desc
+			}
+			if old := oldNames(def.Item, namePrefix, envPrefix); old != "" {
+				desc += fmt.Sprintf(AliasesFormat, old)
+			}
+			ew.printf(LineFormatMD, n, e, typ, de, desc)
 		}
 	}
 	for _, def := range childs {
+		if ew.err != nil {
+			break
+		}
+		if def.IsCommand {
+			// Параметры команды не получают ее имя в префикс
+			if onlyEnv {
+				ew.printf(CommandFormatMk, def.Name, def.Description)
+			} else {
+				ew.printf(CommandFormatMD, def.Name, def.Description)
+			}
+			if ew.err == nil {
+				ew.err = WriteConfigM(w, def.Group.Items, onlyEnv, namePrefix, envPrefix, CommandTitle)
+			}
+			continue
+		}
 		// Подключаем вложенные группы
 		np := namePrefix + def.Name
 		ep := envPrefix + def.Env
-		PrintConfigM(def.Group.Items, onlyEnv, np, ep, def.Description)
+		ew.err = WriteConfigM(w, def.Group.Items, onlyEnv, np, ep, def.Description)
 	}
+	return ew.err
+}
+
+// oldNames returns deprecated names of option for Markdown.
+func oldNames(item *ItemDef, namePrefix, envPrefix string) string {
+	names := make([]string, 0, len(item.Aliases)+len(item.EnvAliases))
+	for _, a := range item.Aliases {
+		names = append(names, "`--"+namePrefix+a+"`")
+	}
+	for _, a := range item.EnvAliases {
+		names = append(names, "`"+envPrefix+a+"`")
+	}
+	return strings.Join(names, ", ")
 }
 
 // FetchDefs fetch config definitions from Config struct.
+// ENV names of top level options and groups include prefix from SetEnvPrefix.
 func FetchDefs(obj any) []Def {
+	defs := fetchDefs(obj)
+	if defs == nil || appEnvPrefix == "" {
+		return defs
+	}
+	return prefixEnv(defs, appEnvPrefix)
+}
+
+// prefixEnv returns copy of defs with ENV names prefixed.
+// Groups without env-namespace and commands do not change ENV names of their items.
+func prefixEnv(defs []Def, prefix string) []Def {
+	rv := make([]Def, len(defs))
+	for i, def := range defs {
+		switch {
+		case def.Group != nil && (def.IsCommand || def.Env == ""):
+			group := *def.Group
+			group.Items = prefixEnv(group.Items, prefix)
+			def.Group = &group
+		case def.Env != "":
+			def.Env = envJoin(prefix, def.Env)
+			if def.Item != nil && len(def.Item.EnvAliases) > 0 {
+				item := *def.Item
+				item.EnvAliases = make([]string, len(def.Item.EnvAliases))
+				for j, a := range def.Item.EnvAliases {
+					item.EnvAliases[j] = envJoin(prefix, a)
+				}
+				def.Item = &item
+			}
+		}
+		rv[i] = def
+	}
+	return rv
+}
+
+// fetchDefs fetch config definitions from struct.
+func fetchDefs(obj any) []Def {
 
 	v := reflect.ValueOf(obj)
 
@@ -180,15 +281,15 @@ func FetchDefs(obj any) []Def {
 		}
 
 		if fv.CanInterface() {
-			// Пропускаем nil-указатели
+			// для nil-указателя описываем пустую структуру, т.к. go-flags создаст ее при разборе
 			if fv.Kind() == reflect.Ptr && fv.IsNil() {
-				continue
+				fv = reflect.New(ft)
 			}
-			if def.IsGroup {
-				def.Group = &GroupDef{Items: FetchDefs(fv.Interface())}
+			if def.IsGroup || def.IsCommand {
+				def.Group = &GroupDef{Items: fetchDefs(fv.Interface())}
 				rv = append(rv, *def)
 			} else {
-				siblings := FetchDefs(fv.Interface())
+				siblings := fetchDefs(fv.Interface())
 				rv = append(rv, siblings...)
 			}
 		}
@@ -201,7 +302,8 @@ func FetchDefs(obj any) []Def {
 var reOptions = regexp.MustCompile(`choice:"([^"]*)"`)
 
 // Список тегов, поддерживаемых https://github.com/jessevdk/go-flags/
-var tagFields = []string{"hidden", "env", "default", "long", "choice", "description", "group", "namespace", "env-namespace", "positional-arg-name"}
+var tagFields = []string{"hidden", "env", "default", "long", "choice", "description", "group", "namespace", "env-namespace", "positional-arg-name", "secret", "command",
+	"deprecated", "alias", "env-alias"}
 
 // Извлечение поддерживаемых тегов
 func fetchFields(tag reflect.StructTag) *Def {
@@ -215,6 +317,13 @@ func fetchFields(tag reflect.StructTag) *Def {
 			rv[field] = val
 		}
 	}
+	if rv["command"] != "" {
+		return &Def{
+			Name:        rv["command"],
+			Description: rv["description"],
+			IsCommand:   true,
+		}
+	}
 	if rv["group"] != "" {
 		return &Def{
 			Name:        rv["namespace"],
@@ -243,10 +352,29 @@ func fetchFields(tag reflect.StructTag) *Def {
 		Env:         rv["env"],
 		Description: rv["description"],
 		Item: &ItemDef{
-			Default: rv["default"],
-			Options: result,
+			Default:    rv["default"],
+			Options:    result,
+			Secret:     rv["secret"] == "true",
+			Deprecated: rv["deprecated"],
+			Aliases:    aliases(rv["alias"]),
+			EnvAliases: aliases(rv["env-alias"]),
 		},
 	}
+	if _, ok := tag.Lookup("deprecated"); ok && def.Item.Deprecated == "" {
+		def.Item.Deprecated = DeprecatedHint
+	}
+	def.Item.Profiles = profileTags(tag)
+	if def.Item.Secret {
+		// значение секрета не публикуется
+		if def.Item.Default != "" {
+			def.Item.Default = SecretMask
+		}
+		for profile, val := range def.Item.Profiles {
+			if val != "" {
+				def.Item.Profiles[profile] = SecretMask
+			}
+		}
+	}
 	if def.Name == "" {
 		def.Name = rv["positional-arg-name"]
 	}
@@ -0,0 +1,426 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	flags "github.com/jessevdk/go-flags"
+	"gopkg.in/yaml.v3"
+)
+
+// EnableConfigFile при включении в Config добавляет поддержку `--config`.
+type EnableConfigFile struct {
+	GoKitConfigFileOption string `description:"Load config from given JSON, YAML or TOML file" long:"config" env:"CONFIG"`
+}
+
+// IsConfigFileRequested доступен, если в структуру встроен `EnableConfigFile`.
+type IsConfigFileRequested interface {
+	GoKitConfigFileRequested() string
+}
+
+// Проверяем, что EnableConfigFile implements IsConfigFileRequested.
+var _ IsConfigFileRequested = (*EnableConfigFile)(nil)
+
+// GoKitConfigFileRequested returns config file name if given.
+func (opt EnableConfigFile) GoKitConfigFileRequested() string {
+	return opt.GoKitConfigFileOption
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// preload sets option defaults from profile tags and config sources requested by cfg mixins,
+// from provider values and from secret files.
+// Values loaded this way override struct tag defaults, but ENV and command
+// line flags are still applied by go-flags on top of them.
+// It returns sources of loaded values by option name.
+func preload(idx map[string]*flags.Option, cfg any, args []string, remote map[string]string, o openOptions) (map[string]string, error) {
+	values := map[string][]string{}
+	sources := map[string]string{}
+	var profile string
+	if hasPreloadMixins(cfg) {
+		scratch := preParse(cfg, args, o.env)
+		if v, ok := scratch.(IsProfileRequested); ok {
+			profile = v.GoKitConfigProfile()
+			if err := checkProfile(profile); err != nil {
+				return nil, err
+			}
+			mergeValues(values, sources, profileValues(idx, profile), SourceProfile+" "+profile)
+		}
+		if err := loadSources(idx, cfg, scratch, profile, values, sources); err != nil {
+			return nil, err
+		}
+	}
+	vals := map[string][]string{}
+	providerValues(idx, remote, vals)
+	mergeValues(values, sources, vals, SourceProvider)
+	envAliases(idx, o.lookupEnv, values, sources)
+	if o.env != nil {
+		// ENV из WithEnv применяем сами, go-flags его не видит
+		vals = map[string][]string{}
+		providerValues(idx, o.env, vals)
+		for name, val := range vals {
+			values[name] = val
+			sources[name] = SourceEnv + " " + idx[name].EnvKeyWithNamespace()
+		}
+	}
+	if err := secretFiles(idx, o.lookupEnv, values, sources); err != nil {
+		return nil, err
+	}
+	applyValues(idx, values)
+	maskSecrets(idx)
+	setGenFormats(idx)
+	return sources, nil
+}
+
+// isFile returns true if name is an existing file.
+func isFile(name string) bool {
+	fi, err := os.Stat(name)
+	return err == nil && !fi.IsDir()
+}
+
+// hasPreloadMixins returns true if cfg options are needed before the main parse.
+func hasPreloadMixins(cfg any) bool {
+	_, isFile := cfg.(IsConfigFileRequested)
+	_, isLoad := cfg.(IsLoadRequested)
+	_, isProfile := cfg.(IsProfileRequested)
+	return isFile || isLoad || isProfile
+}
+
+// mergeValues copies vals to values and marks them with source.
+func mergeValues(values map[string][]string, sources map[string]string, vals map[string][]string, source string) {
+	for name, val := range vals {
+		values[name] = val
+		sources[name] = source
+	}
+}
+
+// loadSources loads values from files given in `--config` (with profile overlay) and `--config_load`.
+// scratch holds values of mixin options.
+func loadSources(idx map[string]*flags.Option, cfg, scratch any, profile string,
+	values map[string][]string, sources map[string]string,
+) error {
+	if v, ok := scratch.(IsConfigFileRequested); ok {
+		if name := v.GoKitConfigFileRequested(); name != "" {
+			names := []string{name}
+			if profile != "" {
+				// файл профиля не обязателен
+				if overlay := profileFile(name, profile); isFile(overlay) {
+					names = append(names, overlay)
+				}
+			}
+			lookup := func(key string) (*flags.Option, bool) {
+				opt, ok := idx[key]
+				return opt, ok
+			}
+			for _, name := range names {
+				data, err := loadFile(name)
+				if err != nil {
+					return err
+				}
+				vals := map[string][]string{}
+				if err := fileValues(lookup, "", data, vals); err != nil {
+					return fmt.Errorf("config file %s: %w", name, err)
+				}
+				mergeValues(values, sources, vals, "file "+name)
+			}
+		}
+	}
+	if v, ok := scratch.(IsLoadRequested); ok {
+		if name := v.GoKitConfigLoadRequested(); name != "" {
+			data, err := loadJSON(name)
+			if err != nil {
+				return err
+			}
+			names := map[string]string{}
+			fieldNames(reflect.TypeOf(cfg), "", "", names)
+			lookup := func(key string) (*flags.Option, bool) {
+				long, ok := names[key]
+				return idx[long], ok
+			}
+			vals := map[string][]string{}
+			if err := fileValues(lookup, "", data, vals); err != nil {
+				return fmt.Errorf("config dump %s: %w", name, err)
+			}
+			mergeValues(values, sources, vals, "dump "+name)
+		}
+	}
+	return nil
+}
+
+// preParse parses args into a fresh copy of cfg, so mixin options
+// (like config file name) are known before the main parse.
+// Parse errors are ignored here, they will be reported by the main parse.
+// If env is not nil, it is used instead of process ENV.
+func preParse(cfg any, args []string, env map[string]string) any {
+	t := reflect.TypeOf(cfg)
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return cfg
+	}
+	scratch := reflect.New(t.Elem()).Interface()
+	p := newParser(scratch, flags.IgnoreUnknown)
+	if env != nil {
+		idx := optionIndex(p)
+		values := map[string][]string{}
+		providerValues(idx, env, values)
+		applyValues(idx, values)
+		hideEnv(idx)
+	}
+	_, _ = p.ParseArgs(args)
+	return scratch
+}
+
+// optionIndex returns parser options (including options of commands) indexed by long name with namespace.
+func optionIndex(p *flags.Parser) map[string]*flags.Option {
+	rv := map[string]*flags.Option{}
+	var walk func(g *flags.Group)
+	walk = func(g *flags.Group) {
+		for _, opt := range g.Options() {
+			if name := opt.LongNameWithNamespace(); name != "" {
+				rv[name] = opt
+			}
+		}
+		for _, child := range g.Groups() {
+			walk(child)
+		}
+	}
+	var walkCommands func(c *flags.Command)
+	walkCommands = func(c *flags.Command) {
+		walk(c.Group)
+		for _, sub := range c.Commands() {
+			walkCommands(sub)
+		}
+	}
+	walkCommands(p.Command)
+	return rv
+}
+
+// applyValues replaces defaults of options found in values.
+func applyValues(idx map[string]*flags.Option, values map[string][]string) {
+	for name, val := range values {
+		idx[name].Default = val
+	}
+}
+
+// loadFile decodes config file according to its extension.
+func loadFile(name string) (map[string]any, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".json" {
+		return loadJSON(name)
+	}
+	data, err := os.ReadFile(name) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	rv := map[string]any{}
+	switch ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &rv)
+	case ".toml":
+		err = toml.Unmarshal(data, &rv)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return rv, nil
+}
+
+// loadJSON decodes JSON file keeping numbers as json.Number.
+func loadJSON(name string) (map[string]any, error) {
+	data, err := os.ReadFile(name) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	rv := map[string]any{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&rv); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return rv, nil
+}
+
+// fieldNames maps JSON paths of struct fields (as written by SaveJSON) to
+// option long names with namespace. Known fields which are not options
+// (no `long` tag, go-kit mixin settings or masked secrets) are mapped to "".
+func fieldNames(t reflect.Type, jsonPrefix, nsPrefix string, names map[string]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, hasName := field.Name, false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			n, _, _ := strings.Cut(tag, ",")
+			if n == "-" {
+				continue
+			}
+			if n != "" {
+				name, hasName = n, true
+			}
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		long := field.Tag.Get("long")
+		if ft.Kind() == reflect.Struct && long == "" {
+			jp := jsonPrefix + name + "."
+			if field.Anonymous && !hasName {
+				jp = jsonPrefix
+			} else {
+				names[jsonPrefix+name] = ""
+			}
+			np := nsPrefix
+			if ns := field.Tag.Get("namespace"); ns != "" && field.Tag.Get("group") != "" {
+				np = nsPrefix + ns + "."
+			}
+			fieldNames(ft, jp, np, names)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if long == "" || field.Tag.Get("no-flag") != "" || isSecret(field.Tag) ||
+			strings.HasPrefix(field.Name, "GoKitConfig") {
+			names[jsonPrefix+name] = ""
+			continue
+		}
+		names[jsonPrefix+name] = nsPrefix + long
+	}
+}
+
+// fileValues maps nested config data onto options found by lookup.
+// Nested objects are matched by path, so for config file
+// `{"srv": {"tls": {"cert": "x"}}}` sets `--srv.tls.cert`.
+// Lookup returns known=true for keys which must be skipped silently.
+func fileValues(lookup func(string) (*flags.Option, bool), prefix string, data map[string]any, values map[string][]string) error {
+	var errs []error
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		name := prefix + key
+		val := data[key]
+		opt, known := lookup(name)
+		if opt != nil {
+			vals, err := optionValues(opt, val)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("option %s: %w", name, err))
+			} else if vals != nil {
+				values[opt.LongNameWithNamespace()] = vals
+			}
+			continue
+		}
+		if m, ok := val.(map[string]any); ok {
+			if err := fileValues(lookup, name+".", m, values); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if !known {
+			errs = append(errs, fmt.Errorf("unknown option %s", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// optionValues converts decoded value into go-flags default strings.
+func optionValues(opt *flags.Option, val any) ([]string, error) {
+	if val == nil {
+		return nil, nil
+	}
+	t := opt.Field().Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		items, ok := val.([]any)
+		if !ok {
+			items = []any{val}
+		}
+		rv := make([]string, 0, len(items))
+		for _, item := range items {
+			s, err := scalarValue(t.Elem(), item)
+			if err != nil {
+				return nil, err
+			}
+			rv = append(rv, s)
+		}
+		return rv, nil
+	case reflect.Map:
+		m, ok := val.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected object, got %T", val)
+		}
+		delim := opt.Field().Tag.Get("key-value-delimiter")
+		if delim == "" {
+			delim = ":"
+		}
+		rv := make([]string, 0, len(m))
+		for _, k := range slices.Sorted(maps.Keys(m)) {
+			s, err := scalarValue(t.Elem(), m[k])
+			if err != nil {
+				return nil, err
+			}
+			rv = append(rv, k+delim+s)
+		}
+		return rv, nil
+	}
+	s, err := scalarValue(t, val)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+// scalarValue formats decoded scalar value as go-flags argument.
+// Numbers are accepted for time.Duration fields as nanoseconds.
+func scalarValue(t reflect.Type, val any) (string, error) {
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		if t == durationType {
+			if n, err := v.Int64(); err == nil {
+				return time.Duration(n).String(), nil
+			}
+		}
+		return v.String(), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case map[string]any, []any:
+		return "", fmt.Errorf("unexpected %T value", val)
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == durationType {
+			return time.Duration(rv.Int()).String(), nil
+		}
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		if t == durationType {
+			return time.Duration(rv.Float()).String(), nil
+		}
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), nil
+	}
+	return fmt.Sprint(val), nil
+}
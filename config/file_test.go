@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type FileSrvConfig struct {
+	Listen string        `long:"listen" env:"LISTEN" default:":8080"`
+	Grace  time.Duration `long:"grace" default:"10s"`
+	Tags   []string      `long:"tag"`
+}
+
+type FileConfig struct {
+	Name  string        `long:"name" env:"NAME" default:"def"`
+	Debug bool          `long:"debug"`
+	Srv   FileSrvConfig `group:"Server" namespace:"srv" env-namespace:"SRV"`
+	EnableConfigFile
+}
+
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(file, []byte(data), 0o600))
+	return file
+}
+
+func TestConfigFileFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"cfg.json", `{"name": "file", "debug": true, "srv": {"listen": ":9090", "grace": "5s", "tag": ["a", "b"]}}`},
+		{"cfg.yaml", "name: file\ndebug: true\nsrv:\n  listen: \":9090\"\n  grace: 5s\n  tag: [a, b]\n"},
+		{"cfg.toml", "name = \"file\"\ndebug = true\n[srv]\nlisten = \":9090\"\ngrace = \"5s\"\ntag = [\"a\", \"b\"]\n"},
+	}
+	for _, tt := range tests {
+		file := writeFile(t, tt.name, tt.data)
+		cfg := &FileConfig{}
+		err := Open(cfg, "--config", file)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, "file", cfg.Name, tt.name)
+		assert.True(t, cfg.Debug, tt.name)
+		assert.Equal(t, ":9090", cfg.Srv.Listen, tt.name)
+		assert.Equal(t, 5*time.Second, cfg.Srv.Grace, tt.name)
+		assert.Equal(t, []string{"a", "b"}, cfg.Srv.Tags, tt.name)
+	}
+}
+
+func TestConfigFilePrecedence(t *testing.T) {
+	file := writeFile(t, "cfg.json", `{"name": "file", "srv": {"listen": ":9090"}}`)
+
+	t.Setenv("SRV_LISTEN", ":7070")
+	cfg := &FileConfig{}
+	require.NoError(t, Open(cfg, "--config", file, "--name", "flag"))
+	assert.Equal(t, "flag", cfg.Name, "flag wins over file")
+	assert.Equal(t, ":7070", cfg.Srv.Listen, "env wins over file")
+	assert.Equal(t, 10*time.Second, cfg.Srv.Grace, "default used if not in file")
+
+	t.Setenv("CONFIG", file)
+	cfg = &FileConfig{}
+	require.NoError(t, Open(cfg, "--debug"))
+	assert.Equal(t, "file", cfg.Name, "file name from env")
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"cfg.json", `{"srv": {"port": 1}, "nope": true}`, "config file %s: unknown option nope\nunknown option srv.port"},
+		{"cfg.json", `{"srv": {"tag": {"a": 1}}}`, "config file %s: option srv.tag: unexpected map[string]interface {} value"},
+		{"cfg.ini", ``, `unsupported config file format ".ini"`},
+	}
+	for _, tt := range tests {
+		file := writeFile(t, tt.name, tt.data)
+		err := Open(&FileConfig{}, "--config", file)
+		require.ErrorAs(t, err, &ErrBadArgsContainer{})
+		want := tt.err
+		if tt.name == "cfg.json" {
+			want = fmt.Sprintf(tt.err, file)
+		}
+		assert.Equal(t, want, err.Error())
+	}
+}
+
+type LoadConfig struct {
+	FileConfig
+	Nums map[string]int `long:"num"`
+	Sub  FileSrvConfig  `group:"Sub" namespace:"sub"`
+	EnableConfigDump
+	EnableConfigLoad
+}
+
+func TestConfigLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "dump.json")
+	src := &LoadConfig{}
+	require.NoError(t, Open(src, "--name", "dumped", "--debug", "--srv.grace", "1m",
+		"--srv.tag", "x", "--num", "a:1", "--sub.listen", ":1", "--config_dump", file))
+
+	cfg := &LoadConfig{}
+	require.NoError(t, Open(cfg, "--config_load", file))
+	assert.Equal(t, src.FileConfig, cfg.FileConfig)
+	assert.Equal(t, src.Nums, cfg.Nums)
+	assert.Equal(t, src.Sub, cfg.Sub)
+	assert.Equal(t, "", cfg.GoKitConfigDumpOption, "mixin options are not loaded")
+
+	t.Setenv("NAME", "env")
+	cfg = &LoadConfig{}
+	require.NoError(t, Open(cfg, "--config_load", file, "--srv.grace", "2s"))
+	assert.Equal(t, "env", cfg.Name, "env wins over dump")
+	assert.Equal(t, 2*time.Second, cfg.Srv.Grace, "flag wins over dump")
+	assert.Equal(t, ":1", cfg.Sub.Listen)
+}
+
+func TestConfigLoadUnknown(t *testing.T) {
+	file := writeFile(t, "dump", `{"Name": "x", "Srv": {"Port": 1}, "Extra": 1}`)
+	err := Open(&LoadConfig{}, "--config_load", file)
+	require.ErrorAs(t, err, &ErrBadArgsContainer{})
+	assert.Equal(t, fmt.Sprintf("config dump %s: unknown option Extra\nunknown option Srv.Port", file), err.Error())
+}
@@ -0,0 +1,400 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	flags "github.com/jessevdk/go-flags"
+	"gopkg.in/yaml.v3"
+)
+
+// EnableConfigInit при включении в Config добавляет `--config_init`.
+// Для каждого параметра выводятся описание, тип (или допустимые значения) и значение по умолчанию,
+// введенное значение проверяется по типу параметра.
+// Формат файла выбирается по расширению: `.env`, `.json`, `.yaml`, `.yml` или `.toml`.
+// С `--config_init_defaults` (или если stdin - не терминал) вопросы не задаются,
+// в файл пишутся значения по умолчанию.
+type EnableConfigInit struct {
+	GoKitConfigInitOption         string `description:"Create config file (.env, .json, .yaml or .toml) by answering questions and exit" long:"config_init"`
+	GoKitConfigInitDefaultsOption bool   `description:"Accept default values in config_init without questions (for CI)" long:"config_init_defaults"`
+}
+
+// IsConfigInitRequested доступен, если в структуру встроен `EnableConfigInit`.
+type IsConfigInitRequested interface {
+	GoKitConfigInitRequested(cfg any) error
+}
+
+// Проверяем, что EnableConfigInit implements IsConfigInitRequested.
+var _ IsConfigInitRequested = (*EnableConfigInit)(nil)
+
+// GoKitConfigInitRequested runs config init wizard if requested.
+func (opt EnableConfigInit) GoKitConfigInitRequested(cfg any) error {
+	if opt.GoKitConfigInitOption == "" {
+		return nil
+	}
+	useDefaults := opt.GoKitConfigInitDefaultsOption || !isTerminal(os.Stdin)
+	if err := ConfigInit(os.Stdin, os.Stdout, cfg, opt.GoKitConfigInitOption, useDefaults); err != nil {
+		return err
+	}
+	return ErrConfigInit
+}
+
+// isTerminal returns true if f is a character device.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// initItem - параметр, значение которого запрашивает ConfigInit.
+type initItem struct {
+	Path  []string // путь в файле конфигурации (namespace групп и имя)
+	Env   string   // имя ENV с префиксами групп
+	Title string   // название группы
+	Def   Def
+	opt   *flags.Option // параметр в парсере для проверки значения
+	Value string
+}
+
+// initItems возвращает параметры в порядке вывода WriteConfigM.
+func initItems(defs []Def, path []string, envPrefix, title string) []initItem {
+	var rv []initItem
+	childs := []Def{}
+	for _, def := range defs {
+		if def.IsGroup || def.IsCommand {
+			childs = append(childs, def)
+			continue
+		}
+		if def.Name == "" {
+			continue
+		}
+		item := initItem{Path: append(path[:len(path):len(path)], def.Name), Title: title, Def: def}
+		if def.Env != "" {
+			item.Env = envJoin(envPrefix, def.Env)
+		}
+		rv = append(rv, item)
+	}
+	for _, def := range childs {
+		if def.IsCommand {
+			rv = append(rv, initItems(def.Group.Items, path, envPrefix, "Command "+def.Name)...)
+			continue
+		}
+		p := path
+		if def.Name != "" {
+			p = append(path[:len(path):len(path)], def.Name)
+		}
+		rv = append(rv, initItems(def.Group.Items, p, envJoin(envPrefix, def.Env), def.Description)...)
+	}
+	return rv
+}
+
+// name returns option name with group namespaces.
+func (item initItem) name() string {
+	return strings.Join(item.Path, ".")
+}
+
+// defaultValue returns value used for empty answer.
+func (item initItem) defaultValue() string {
+	def := item.Def.Item
+	switch {
+	case def.Secret:
+		// замаскированное значение не подставляем, действует значение из тега
+		return ""
+	case def.Default == "" && def.Type == "bool":
+		return "false"
+	}
+	return def.Default
+}
+
+// prompt returns question about item value.
+func (item initItem) prompt() string {
+	def := item.Def.Item
+	typ := def.Type
+	if def.Options != nil {
+		typ = "one of: " + strings.Join(def.Options, ", ")
+	}
+	rv := fmt.Sprintf("%s (%s)", item.Def.Description, typ)
+	if def.Secret {
+		rv += " [secret]"
+	}
+	if def.Default != "" {
+		rv += " [" + def.Default + "]"
+	}
+	return strings.TrimSpace(rv)
+}
+
+// check validates value by setting it into option of scratch config.
+func (item initItem) check(value string) error {
+	if value == "" {
+		return nil
+	}
+	if item.Def.Item.Type == "bool" {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid bool value %q", value)
+		}
+		return nil
+	}
+	values := []string{value}
+	if item.isList() {
+		values = splitList(value)
+	}
+	for _, v := range values {
+		if err := item.opt.Set(&v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isList returns true if option is a slice or map.
+func (item initItem) isList() bool {
+	k := item.opt.Field().Type.Kind()
+	return k == reflect.Slice || k == reflect.Map
+}
+
+// noEnvList returns true if list option can not be set by ENV (has no `env-delim`).
+func (item initItem) noEnvList() bool {
+	return item.isList() && item.opt.EnvDefaultDelim == ""
+}
+
+// splitList splits comma separated list.
+func splitList(value string) []string {
+	var rv []string
+	for v := range strings.SplitSeq(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			rv = append(rv, v)
+		}
+	}
+	return rv
+}
+
+// ConfigInit запрашивает в in значения параметров cfg и сохраняет их в файл fileName.
+// Пустой ответ (или конец ввода) означает значение по умолчанию, при useDefaults вопросы не задаются.
+// Значения списков и map вводятся через запятую. Существующий файл не перезаписывается.
+func ConfigInit(in io.Reader, out io.Writer, cfg any, fileName string, useDefaults bool) error {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	switch ext {
+	case ".env", ".json", ".yaml", ".yml", ".toml":
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, ext)
+	}
+	items, err := initOptions(cfg, ext == ".env")
+	if err != nil {
+		return err
+	}
+	ew := &errWriter{w: out}
+	scanner := bufio.NewScanner(in)
+	var title string
+	for i := range items {
+		item := &items[i]
+		item.Value = item.defaultValue()
+		if useDefaults || ext == ".env" && item.noEnvList() {
+			continue
+		}
+		if i == 0 || item.Title != title {
+			title = item.Title
+			ew.printf("\n# %s\n", title)
+		}
+		for {
+			ew.printf("%s\n%s: ", item.prompt(), item.name())
+			if !scanner.Scan() {
+				ew.printf("\n")
+				useDefaults = true
+				break
+			}
+			answer := strings.TrimSpace(scanner.Text())
+			if err := item.check(answer); err != nil {
+				ew.printf("Error: %v\n", err)
+				continue
+			}
+			if answer != "" {
+				item.Value = answer
+			}
+			break
+		}
+	}
+	if ew.err != nil {
+		return ew.err
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if ext == ".env" {
+		err = writeInitEnv(&buf, items)
+	} else {
+		err = writeInitFile(&buf, items, ext)
+	}
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", fileName, err)
+	}
+	if _, err = file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("%s %s: %w", errStrClose, fileName, err)
+	}
+	_, err = fmt.Fprintf(out, "Config saved to %s\n", fileName)
+	return err
+}
+
+// initOptions returns options of cfg except ones added by Enable* mixins.
+// Options without ENV are skipped if onlyEnv is set.
+func initOptions(cfg any, onlyEnv bool) ([]initItem, error) {
+	t := reflect.TypeOf(cfg)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config must be a struct, got %s", t)
+	}
+	// значения проверяются на отдельном экземпляре конфига
+	idx := optionIndex(newParser(reflect.New(t).Interface(), flags.None))
+	defs := FetchDefs(cfg)
+	if v, ok := cfg.(IsProfileRequested); ok {
+		defs = profileDefs(defs, v.GoKitConfigProfile())
+	}
+	var rv []initItem
+	for _, item := range initItems(defs, nil, "", "Main Options") {
+		opt, ok := idx[item.name()]
+		if !ok || strings.HasPrefix(opt.Field().Name, "GoKitConfig") {
+			continue
+		}
+		if onlyEnv && item.Env == "" {
+			continue
+		}
+		item.opt = opt
+		rv = append(rv, item)
+	}
+	return rv, nil
+}
+
+// writeInitEnv пишет значения в формате .env файла.
+// Секреты без значения пишутся закомментированными.
+// Элементы списков и map разделяются `env-delim`, если он не задан - параметр пропускается.
+func writeInitEnv(w io.Writer, items []initItem) error {
+	ew := &errWriter{w: w}
+	var title string
+	for i, item := range items {
+		if i == 0 || item.Title != title {
+			title = item.Title
+			ew.printf("\n# %s\n", title)
+		}
+		env := envItem{Env: item.Env, Def: item.Def}
+		if item.noEnvList() {
+			ew.printf("\n# %s\n# %s is skipped: list without env-delim is read as one value\n", env.envComment(), item.Env)
+			continue
+		}
+		value := item.Value
+		if item.isList() {
+			value = strings.Join(splitList(value), item.opt.EnvDefaultDelim)
+		}
+		prefix := ""
+		if value == "" && item.Def.Item.Secret {
+			prefix = "# "
+		}
+		ew.printf("\n# %s\n%s%s=%s\n", env.envComment(), prefix, item.Env, dotenvQuote(value))
+	}
+	return ew.err
+}
+
+// writeInitFile пишет значения в формате JSON, YAML или TOML,
+// пригодном для загрузки через `--config`.
+// Параметры без значения не пишутся.
+func writeInitFile(w io.Writer, items []initItem, ext string) error {
+	data := map[string]any{}
+	for _, item := range items {
+		val := item.fileValue()
+		if val == nil {
+			continue
+		}
+		m := data
+		for _, key := range item.Path[:len(item.Path)-1] {
+			child, ok := m[key].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				m[key] = child
+			}
+			m = child
+		}
+		m[item.Path[len(item.Path)-1]] = val
+	}
+	switch ext {
+	case ".json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	case ".toml":
+		return toml.NewEncoder(w).Encode(data)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(data); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// fileValue converts item value to value of config file.
+// Returns nil for empty value.
+func (item initItem) fileValue() any {
+	if item.Value == "" {
+		return nil
+	}
+	t := item.opt.Field().Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		rv := []any{}
+		for _, v := range splitList(item.Value) {
+			rv = append(rv, v)
+		}
+		return rv
+	case reflect.Map:
+		delim := item.opt.Field().Tag.Get("key-value-delimiter")
+		if delim == "" {
+			delim = ":"
+		}
+		rv := map[string]any{}
+		for _, v := range splitList(item.Value) {
+			key, val, _ := strings.Cut(v, delim)
+			rv[key] = val
+		}
+		return rv
+	case reflect.Bool:
+		if v, err := strconv.ParseBool(item.Value); err == nil {
+			return v
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == durationType {
+			break
+		}
+		if v, err := strconv.ParseInt(item.Value, 10, 64); err == nil {
+			return v
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v, err := strconv.ParseUint(item.Value, 10, 64); err == nil {
+			return v
+		}
+	case reflect.Float32, reflect.Float64:
+		if v, err := strconv.ParseFloat(item.Value, 64); err == nil {
+			return v
+		}
+	}
+	return item.Value
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type InitDB struct {
+	Pool     int    `long:"pool" env:"POOL" default:"1" description:"Pool size"`
+	Password string `long:"password" env:"PASSWORD" default:"def" secret:"true" description:"DB password"`
+}
+
+type InitConfig struct {
+	EnableConfigFile
+	EnableConfigInit
+	Level string   `long:"level" env:"LEVEL" default:"info" choice:"debug" choice:"info" description:"Log level"`
+	Debug bool     `long:"debug" env:"DEBUG" description:"Debug mode"`
+	Hosts []string `long:"host" description:"Hosts"`
+	DB    InitDB   `group:"DB Options" namespace:"db" env-namespace:"DB"`
+}
+
+func TestConfigInit(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.yaml")
+	in := strings.Join([]string{
+		"trace", // not in choices
+		"debug",
+		"yes", // not bool
+		"true",
+		"a.local, b.local",
+		"many", // not int
+		"5",
+		"", // secret keeps tag default
+	}, "\n")
+	var out bytes.Buffer
+	require.NoError(t, ConfigInit(strings.NewReader(in), &out, &InitConfig{}, file, false))
+	assert.Contains(t, out.String(), "Log level (one of: debug, info) [info]\nlevel: ")
+	assert.Contains(t, out.String(), "Error: Invalid value `trace'")
+	assert.Contains(t, out.String(), "Error: invalid bool value \"yes\"")
+	assert.Contains(t, out.String(), "# DB Options\nPool size (int) [1]\ndb.pool: ")
+	assert.Contains(t, out.String(), "DB password (string) [secret] [******]\ndb.password: ")
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	want := `db:
+  pool: 5
+debug: true
+host:
+  - a.local
+  - b.local
+level: debug
+`
+	assert.Equal(t, want, string(data))
+
+	var cfg InitConfig
+	require.NoError(t, Open(&cfg, "--config", file))
+	assert.Equal(t, "debug", cfg.Level)
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, []string{"a.local", "b.local"}, cfg.Hosts)
+	assert.Equal(t, InitDB{Pool: 5, Password: "def"}, cfg.DB)
+
+	// существующий файл не перезаписывается
+	err = ConfigInit(strings.NewReader(""), &out, &InitConfig{}, file, true)
+	require.ErrorIs(t, err, os.ErrExist)
+}
+
+func TestConfigInitEnv(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+	var out bytes.Buffer
+	// конец ввода - значения по умолчанию
+	require.NoError(t, ConfigInit(strings.NewReader("debug\n"), &out, &InitConfig{}, file, false))
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	want := `
+# Main Options
+
+# Log level (debug,info)
+LEVEL=debug
+
+# Debug mode (bool)
+DEBUG=false
+
+# DB Options
+
+# Pool size (int)
+DB_POOL=1
+
+# DB password (string) [secret]
+# DB_PASSWORD=
+`
+	assert.Equal(t, want, string(data))
+}
+
+func TestConfigInitDefaults(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.json")
+	var cfg InitConfig
+	err := Open(&cfg, "--config_init", file, "--config_init_defaults")
+	require.ErrorIs(t, err, ErrConfigInit)
+	assert.Equal(t, ExitNormal, ExitCode(err))
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	want := `{
+  "db": {
+    "pool": 1
+  },
+  "debug": false,
+  "level": "info"
+}
+`
+	assert.Equal(t, want, string(data))
+
+	err = ConfigInit(strings.NewReader(""), &bytes.Buffer{}, &cfg, filepath.Join(dir, "app.ini"), true)
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestConfigInitRequired(t *testing.T) {
+	type S struct {
+		EnableConfigInit
+		Token string `long:"token" env:"TOKEN" required:"true" description:"API token"`
+	}
+	file := filepath.Join(t.TempDir(), ".env")
+	err := Open(&S{}, "--config_init", file, "--config_init_defaults")
+	require.ErrorIs(t, err, ErrConfigInit, "required options are not checked")
+	assert.FileExists(t, file)
+}
+
+func TestConfigInitEnvList(t *testing.T) {
+	type S struct {
+		Hosts []string          `long:"host" env:"HOSTS" env-delim:";" description:"Hosts"`
+		Tags  []string          `long:"tag" env:"TAGS" description:"Tags"`
+		Attrs map[string]string `long:"attr" env:"ATTRS" env-delim:"," description:"Attributes"`
+	}
+	file := filepath.Join(t.TempDir(), ".env")
+	in := "a.local, b.local\nk1:v1,k2:v2\n"
+	require.NoError(t, ConfigInit(strings.NewReader(in), &bytes.Buffer{}, &S{}, file, false))
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "\nHOSTS=a.local;b.local\n")
+	assert.Contains(t, string(data), "\n# TAGS is skipped: list without env-delim is read as one value\n")
+	assert.Contains(t, string(data), "\nATTRS=k1:v1,k2:v2\n")
+
+	var cfg S
+	require.NoError(t, OpenWith(&cfg, WithArgs(), WithEnv(map[string]string{"HOSTS": "a.local;b.local", "ATTRS": "k1:v1,k2:v2"})))
+	assert.Equal(t, []string{"a.local", "b.local"}, cfg.Hosts)
+	assert.Equal(t, map[string]string{"k1": "v1", "k2": "v2"}, cfg.Attrs)
+}
@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
+	"unicode"
+
+	flags "github.com/jessevdk/go-flags"
 )
 
 var (
@@ -12,12 +16,17 @@ var (
 	// Values might be set from caller in SetApplicationVersion function.
 	application = "app"
 	version     = "0.0-dev"
+
+	// appEnvPrefix might be set from caller in SetEnvPrefix function.
+	appEnvPrefix string
 )
 
 // EnableShowVersion при включении в Config добавляет поддержку `--version`.
 type EnableShowVersion struct {
 	// GoKitConfigShowVersionOption - флаг для вывода версии приложения.
 	GoKitConfigShowVersionOption bool `description:"Show version and exit" long:"version"`
+	// GoKitConfigVersionFormat - формат вывода сведений о сборке (см. BuildInfo).
+	GoKitConfigVersionFormat string `description:"Show build info in given format and exit (default: '', means skip)" long:"version_format" choice:"" choice:"text" choice:"json"`
 }
 
 // IsShowVersionRequested доступен, если в структуру встроен `EnableShowVersion`.
@@ -29,6 +38,12 @@ type IsShowVersionRequested interface {
 var _ IsShowVersionRequested = (*EnableShowVersion)(nil)
 
 func (opt EnableShowVersion) GoKitConfigShowVersionRequested() error {
+	if opt.GoKitConfigVersionFormat != "" {
+		if err := WriteBuildInfo(os.Stdout, ReadBuildInfo(), opt.GoKitConfigVersionFormat); err != nil {
+			return err
+		}
+		return ErrVersion
+	}
 	if opt.GoKitConfigShowVersionOption {
 		fmt.Println(application, version)
 		return ErrVersion
@@ -41,9 +56,42 @@ func SetApplicationVersion(app, ver string) {
 	version = ver
 }
 
+// SetEnvPrefix задает префикс имен ENV всех параметров приложения:
+// после SetEnvPrefix("MYAPP") параметр группы `SRV` читается из `MYAPP_SRV_LISTEN`.
+// Префикс учитывается в FetchDefs и, соответственно, в `--config_gen`.
+//
+//	config.SetApplicationVersion(application, version)
+//	config.SetEnvPrefix(config.EnvPrefix(application))
+func SetEnvPrefix(prefix string) {
+	appEnvPrefix = prefix
+}
+
+// EnvPrefix returns ENV prefix for application name: `my-app` -> `MY_APP`.
+func EnvPrefix(app string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, app))
+}
+
+// newParser returns go-flags parser with application ENV prefix.
+func newParser(data any, options flags.Options) *flags.Parser {
+	p := flags.NewParser(data, options)
+	if appEnvPrefix != "" {
+		// префикс нужен и корневой группе (параметры команд), и группе data
+		p.EnvNamespace = appEnvPrefix
+		for _, g := range p.Groups() {
+			g.EnvNamespace = appEnvPrefix
+		}
+	}
+	return p
+}
+
 // EnableConfigDefGen содержит настройки для поддержки `config_gen`.
 type EnableConfigDefGen struct {
-	GoKitConfigDefGenOption string `description:"Generate and print config definition in given format and exit (default: '', means skip)" long:"config_gen" env:"CONFIG_GEN" choice:"" choice:"json" choice:"md" choice:"mk"`
+	GoKitConfigDefGenOption string `description:"Generate and print config definition in given format and exit (default: '', means skip)" long:"config_gen" env:"CONFIG_GEN" choice:"" choice:"json" choice:"jsonschema" choice:"md" choice:"mk" choice:"env" choice:"compose" choice:"k8s"`
 }
 
 type IsDefGenRequested interface {
@@ -54,7 +102,9 @@ var _ IsDefGenRequested = (*EnableConfigDefGen)(nil)
 
 func (opt EnableConfigDefGen) GoKitConfigDefGenRequested(cfg any) error {
 	if opt.GoKitConfigDefGenOption != "" {
-		PrintConfig(cfg, opt.GoKitConfigDefGenOption)
+		if err := PrintConfig(cfg, opt.GoKitConfigDefGenOption); err != nil {
+			return err
+		}
 		return ErrConfGen
 	}
 	return nil
@@ -81,8 +131,38 @@ func (opt EnableConfigDump) GoKitConfigDumpRequested(cfg any) error {
 	return nil
 }
 
+// EnableConfigLoad содержит настройки для поддержки `config_load`.
+type EnableConfigLoad struct {
+	GoKitConfigLoadOption string `description:"Load config from file saved by config_dump" long:"config_load" env:"CONFIG_LOAD"`
+}
+
+// IsLoadRequested доступен, если в структуру встроен `EnableConfigLoad`.
+type IsLoadRequested interface {
+	GoKitConfigLoadRequested() string
+}
+
+var _ IsLoadRequested = (*EnableConfigLoad)(nil)
+
+// GoKitConfigLoadRequested returns config dump file name if given.
+func (opt EnableConfigLoad) GoKitConfigLoadRequested() string {
+	return opt.GoKitConfigLoadOption
+}
+
+// ProcessOptions выполняет действия встроенных в cfg Enable* структур.
 func ProcessOptions(cfg any) error {
+	if err := processPrintOptions(cfg); err != nil {
+		return err
+	}
+	if v, ok := cfg.(IsConfigInitRequested); ok {
+		if err := v.GoKitConfigInitRequested(cfg); err != nil {
+			return err
+		}
+	}
+	return processSaveOptions(cfg)
+}
 
+// processPrintOptions выполняет действия, которые печатают результат и завершают работу.
+func processPrintOptions(cfg any) error {
 	if v, ok := cfg.(IsShowVersionRequested); ok {
 		if err := v.GoKitConfigShowVersionRequested(); err != nil {
 			return err
@@ -93,6 +173,21 @@ func ProcessOptions(cfg any) error {
 			return err
 		}
 	}
+	if v, ok := cfg.(IsExplainRequested); ok {
+		if err := v.GoKitConfigExplainRequested(cfg); err != nil {
+			return err
+		}
+	}
+	if v, ok := cfg.(IsCompletionRequested); ok {
+		if err := v.GoKitConfigCompletionRequested(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processSaveOptions выполняет действия, которые сохраняют конфиг, и вызывается после проверки значений.
+func processSaveOptions(cfg any) error {
 	if v, ok := cfg.(IsDumpRequested); ok {
 		if err := v.GoKitConfigDumpRequested(cfg); err != nil {
 			return err
@@ -104,6 +199,7 @@ func ProcessOptions(cfg any) error {
 const errStrClose = "failed to close file"
 
 // SaveJSON сохраняет данные в файл в формате JSON.
+// Значения полей с тегом `secret:"true"` маскируются.
 func SaveJSON(fileName string, data any) error {
 	file, err := os.Create(fileName) //nolint:gosec
 	if err != nil {
@@ -116,7 +212,7 @@ func SaveJSON(fileName string, data any) error {
 		}
 	}()
 
-	val, err := json.MarshalIndent(data, "", "    ")
+	val, err := json.MarshalIndent(Redact(data), "", "    ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// ProfileTagPrefix - префикс тега значения по умолчанию для профиля (`default-prod:"warn"`).
+const ProfileTagPrefix = "default-"
+
+// ErrBadProfile returned when profile name is not a word (letters, digits, `_` and `-`).
+var ErrBadProfile = errors.New("invalid profile name")
+
+// Имя профиля входит в имя файла, поэтому разделители пути и `..` недопустимы.
+var reProfileName = regexp.MustCompile(`^[\w-]+$`)
+
+// EnableProfile при включении в Config добавляет поддержку `--profile`.
+// Для выбранного профиля значения по умолчанию берутся из тегов `default-<profile>`,
+// а к файлу `--config` добавляется файл профиля (`app.yaml` -> `app.prod.yaml`), если он существует.
+type EnableProfile struct {
+	GoKitConfigProfileOption string `description:"Config profile (dev, stage, prod etc)" env:"PROFILE" long:"profile"`
+}
+
+// IsProfileRequested доступен, если в структуру встроен `EnableProfile`.
+type IsProfileRequested interface {
+	GoKitConfigProfile() string
+}
+
+// Проверяем, что EnableProfile implements IsProfileRequested.
+var _ IsProfileRequested = (*EnableProfile)(nil)
+
+// GoKitConfigProfile returns profile name.
+func (opt EnableProfile) GoKitConfigProfile() string {
+	return opt.GoKitConfigProfileOption
+}
+
+// checkProfile returns error if profile name is not empty and may not be used in file name.
+func checkProfile(profile string) error {
+	if profile != "" && !reProfileName.MatchString(profile) {
+		return fmt.Errorf("%w: %q", ErrBadProfile, profile)
+	}
+	return nil
+}
+
+// profileValues returns values of options from `default-<profile>` tags.
+func profileValues(idx map[string]*flags.Option, profile string) map[string][]string {
+	rv := map[string][]string{}
+	if profile == "" {
+		return rv
+	}
+	for name, opt := range idx {
+		if val, ok := opt.Field().Tag.Lookup(ProfileTagPrefix + profile); ok {
+			rv[name] = []string{val}
+		}
+	}
+	return rv
+}
+
+// profileFile returns name of profile overlay for config file name.
+func profileFile(name, profile string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + profile + ext
+}
+
+// Компилируем регулярное выражение для поиска тегов default-<profile>:"..."
+var reProfiles = regexp.MustCompile(`(?:^|\s)` + ProfileTagPrefix + `([\w.-]+):("(?:[^"\\]|\\.)*")`)
+
+// profileTags returns values of `default-<profile>` tags by profile name.
+func profileTags(tag reflect.StructTag) map[string]string {
+	matches := reProfiles.FindAllStringSubmatch(string(tag), -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	rv := make(map[string]string, len(matches))
+	for _, match := range matches {
+		if val, err := strconv.Unquote(match[2]); err == nil {
+			rv[match[1]] = val
+		}
+	}
+	return rv
+}
+
+// profileDefs returns copy of defs with defaults of given profile.
+func profileDefs(defs []Def, profile string) []Def {
+	if profile == "" {
+		return defs
+	}
+	rv := make([]Def, len(defs))
+	for i, def := range defs {
+		switch {
+		case def.Group != nil:
+			group := *def.Group
+			group.Items = profileDefs(group.Items, profile)
+			def.Group = &group
+		case def.Item != nil:
+			val, ok := def.Item.Profiles[profile]
+			if !ok {
+				break
+			}
+			item := *def.Item
+			item.Default = val
+			def.Item = &item
+		}
+		rv[i] = def
+	}
+	return rv
+}
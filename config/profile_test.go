@@ -0,0 +1,114 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ProfileDB struct {
+	Pool     int    `long:"pool" env:"POOL" default:"1" default-prod:"20" description:"Pool size"`
+	Password string `long:"password" env:"PASSWORD" default:"dev" default-prod:"" secret:"true"`
+}
+
+type ProfileConfig struct {
+	EnableProfile
+	EnableConfigFile
+	EnableConfigExplain
+	Level string    `long:"level" env:"LEVEL" default:"info" default-dev:"debug" default-prod:"warn" description:"Log level"`
+	Root  string    `long:"root" default:"/var/www"`
+	DB    ProfileDB `group:"DB" namespace:"db" env-namespace:"DB"`
+}
+
+func TestProfile(t *testing.T) {
+	var cfg ProfileConfig
+	require.NoError(t, OpenWith(&cfg, WithArgs(), WithEnv(nil)))
+	assert.Equal(t, "info", cfg.Level)
+	assert.Equal(t, 1, cfg.DB.Pool)
+
+	cfg = ProfileConfig{}
+	require.NoError(t, OpenWith(&cfg, WithArgs("--profile", "prod"), WithEnv(nil)))
+	assert.Equal(t, "warn", cfg.Level)
+	assert.Equal(t, 20, cfg.DB.Pool)
+	assert.Empty(t, cfg.DB.Password)
+
+	cfg = ProfileConfig{}
+	require.NoError(t, OpenWith(&cfg, WithArgs("--level", "error"), WithEnv(map[string]string{"PROFILE": "dev"})))
+	assert.Equal(t, "dev", cfg.GoKitConfigProfileOption)
+	assert.Equal(t, "error", cfg.Level, "flag overrides profile")
+	assert.Equal(t, 1, cfg.DB.Pool)
+}
+
+func TestProfileFile(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.WriteFile(base, []byte("level: file\nroot: /base\n"), 0o600))
+	overlay := filepath.Join(dir, "app.stage.yaml")
+	require.NoError(t, os.WriteFile(overlay, []byte("root: /stage\n"), 0o600))
+
+	var cfg ProfileConfig
+	require.NoError(t, OpenWith(&cfg, WithArgs("--config", base, "--profile", "stage"), WithEnv(nil)))
+	assert.Equal(t, "file", cfg.Level)
+	assert.Equal(t, "/stage", cfg.Root)
+
+	got := map[string]Origin{}
+	for _, o := range cfg.Origins() {
+		got[o.Name] = o
+	}
+	assert.Equal(t, "file "+overlay, got["root"].Source)
+
+	cfg = ProfileConfig{}
+	require.NoError(t, OpenWith(&cfg, WithArgs("--config", base, "--profile", "prod"), WithEnv(nil)))
+	assert.Equal(t, "file", cfg.Level, "file overrides profile default")
+	assert.Equal(t, "/base", cfg.Root, "overlay is optional")
+	for _, o := range cfg.Origins() {
+		got[o.Name] = o
+	}
+	assert.Equal(t, "profile prod", got["db.pool"].Source)
+	assert.Equal(t, "20", got["db.pool"].Default)
+	assert.Equal(t, "warn", got["level"].Default, "profile default is shown when file overrides it")
+
+	for _, profile := range []string{"../secret", "a/b", "prod.yaml"} {
+		err := OpenWith(&ProfileConfig{}, WithArgs("--config", base, "--profile", profile), WithEnv(nil))
+		require.ErrorIs(t, err, ErrBadProfile, profile)
+		assert.Equal(t, ExitBadArgs, ExitCode(err))
+	}
+}
+
+func TestProfileDefaults(t *testing.T) {
+	cfg, err := ProfileDefaults(ProfileConfig{Root: "/app"}, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "warn", cfg.Level)
+	assert.Equal(t, "/app", cfg.Root)
+	assert.Equal(t, 20, cfg.DB.Pool)
+
+	cfg, err = Defaults(ProfileConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "info", cfg.Level)
+}
+
+func TestProfileConfigGen(t *testing.T) {
+	items := map[string]*ItemDef{}
+	for _, def := range FetchDefs(ProfileConfig{}) {
+		if def.IsGroup {
+			for _, item := range def.Group.Items {
+				items[def.Name+"."+item.Name] = item.Item
+			}
+			continue
+		}
+		items[def.Name] = def.Item
+	}
+	assert.Equal(t, map[string]string{"dev": "debug", "prod": "warn"}, items["level"].Profiles)
+	assert.Equal(t, map[string]string{"prod": ""}, items["db.password"].Profiles)
+	assert.Nil(t, items["root"].Profiles)
+
+	cfg := ProfileConfig{EnableProfile: EnableProfile{GoKitConfigProfileOption: "prod"}}
+	var buf bytes.Buffer
+	require.NoError(t, WriteConfig(&buf, cfg, "mk"))
+	assert.Contains(t, buf.String(), "#- Log level (string) [warn]\nLEVEL                ?= warn\n")
+	assert.Contains(t, buf.String(), "DB_POOL              ?= 20\n")
+}
@@ -0,0 +1,350 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// Provider загружает значения параметров из внешнего источника (Consul, etcd, HTTP и т.п.).
+// Ключи - имена ENV параметров с префиксами групп (как в `--config_gen=env`, например `DB_POOL`),
+// ключи, не соответствующие параметрам, игнорируются.
+type Provider interface {
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// WatchProvider - Provider, который сообщает об изменении значений.
+// Watch работает до завершения ctx и вызывает notify после каждого изменения.
+type WatchProvider interface {
+	Provider
+	Watch(ctx context.Context, notify func()) error
+}
+
+// OpenOption задает параметр OpenWith.
+type OpenOption func(*openOptions)
+
+type openOptions struct {
+	ctx       context.Context
+	args      []string
+	providers []Provider
+	env       map[string]string
+	reload    bool // parse for Watcher: ProcessOptions is not called
+}
+
+func newOpenOptions(opts []OpenOption) openOptions {
+	rv := openOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&rv)
+	}
+	return rv
+}
+
+// WithArgs задает аргументы командной строки вместо os.Args[1:].
+// Без аргументов задает пустой список, что позволяет вызывать OpenWith в тестах.
+func WithArgs(args ...string) OpenOption {
+	return func(o *openOptions) {
+		o.args = append([]string{}, args...)
+	}
+}
+
+// WithProvider добавляет источник значений. Значения применяются в порядке
+// `default` < файл < Provider < ENV < флаги, из нескольких Provider важнее последний.
+func WithProvider(p Provider) OpenOption {
+	return func(o *openOptions) {
+		o.providers = append(o.providers, p)
+	}
+}
+
+// WithContext задает контекст для запросов к Provider.
+func WithContext(ctx context.Context) OpenOption {
+	return func(o *openOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithEnv задает значения ENV вместо переменных окружения процесса (в т.ч. для `*_FILE` секретов),
+// переменные окружения процесса при этом не используются.
+// Позволяет проверять настройку из ENV в параллельных тестах без os.Setenv.
+// В справке `-h` имена ENV в этом случае не выводятся.
+func WithEnv(env map[string]string) OpenOption {
+	return func(o *openOptions) {
+		o.env = maps.Clone(env)
+		if o.env == nil {
+			o.env = map[string]string{}
+		}
+	}
+}
+
+// lookupEnv returns ENV value from WithEnv or process environment.
+func (o openOptions) lookupEnv(key string) (string, bool) {
+	if o.env == nil {
+		return os.LookupEnv(key)
+	}
+	val, ok := o.env[key]
+	return val, ok
+}
+
+// hideEnv disables reading of process ENV by go-flags
+// and returns function which restores ENV names of options.
+func hideEnv(idx map[string]*flags.Option) (restore func()) {
+	keys := make(map[*flags.Option]string, len(idx))
+	for _, opt := range idx {
+		keys[opt] = opt.EnvDefaultKey
+		opt.EnvDefaultKey = ""
+	}
+	return func() {
+		for opt, key := range keys {
+			opt.EnvDefaultKey = key
+		}
+	}
+}
+
+// fetchProviders загружает значения всех providers.
+func fetchProviders(ctx context.Context, providers []Provider) (map[string]string, error) {
+	rv := map[string]string{}
+	for _, p := range providers {
+		data, err := p.Fetch(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("config provider: %w", err)
+		}
+		maps.Copy(rv, data)
+	}
+	return rv, nil
+}
+
+// helpRequested returns true if args hold help or version flags, so config values are not used.
+func helpRequested(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		name, _, _ := strings.Cut(arg, "=")
+		switch name {
+		case "-h", "--help", "--version", "--version_format":
+			return true
+		}
+	}
+	return false
+}
+
+// providerValues adds values of options with ENV names found in remote.
+func providerValues(idx map[string]*flags.Option, remote map[string]string, values map[string][]string) {
+	if len(remote) == 0 {
+		return
+	}
+	for name, opt := range idx {
+		key := opt.EnvKeyWithNamespace()
+		if key == "" {
+			continue
+		}
+		val, ok := remote[key]
+		if !ok {
+			continue
+		}
+		if opt.EnvDefaultDelim != "" {
+			values[name] = strings.Split(val, opt.EnvDefaultDelim)
+		} else {
+			values[name] = []string{val}
+		}
+	}
+}
+
+// HTTPProvider загружает значения из JSON объекта, который возвращает GET запрос к URL.
+// Вложенные объекты допустимы, их ключи объединяются через "_" (`{"DB":{"POOL":4}}` задает `DB_POOL`).
+// Нулевые Client и Interval означают http.DefaultClient и WatchInterval.
+type HTTPProvider struct {
+	URL    string
+	Client *http.Client
+	Header http.Header
+	// Interval - период опроса URL в Watch.
+	Interval time.Duration
+}
+
+// Проверяем, что HTTPProvider implements WatchProvider.
+var _ WatchProvider = (*HTTPProvider)(nil)
+
+// NewHTTPProvider returns HTTPProvider for given URL.
+func NewHTTPProvider(url string) *HTTPProvider {
+	return &HTTPProvider{
+		URL:      url,
+		Client:   http.DefaultClient,
+		Header:   http.Header{},
+		Interval: WatchInterval,
+	}
+}
+
+// Fetch loads values from URL.
+func (p *HTTPProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, vals := range p.Header {
+		req.Header[key] = vals
+	}
+	req.Header.Set("Accept", "application/json")
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", p.URL, resp.Status)
+	}
+	data := map[string]any{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return nil, fmt.Errorf("%s: failed to decode: %w", p.URL, err)
+	}
+	rv := map[string]string{}
+	if err := flattenValues("", data, rv); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.URL, err)
+	}
+	return rv, nil
+}
+
+// Watch polls URL every Interval and calls notify if values were changed.
+// Fetch errors are not fatal, last known values are kept.
+func (p *HTTPProvider) Watch(ctx context.Context, notify func()) error {
+	last, _ := p.Fetch(ctx)
+	interval := p.Interval
+	if interval <= 0 {
+		interval = WatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		next, err := p.Fetch(ctx)
+		if err != nil || maps.Equal(next, last) {
+			continue
+		}
+		last = next
+		notify()
+	}
+}
+
+// flattenValues converts JSON object to ENV name/value pairs.
+func flattenValues(prefix string, data map[string]any, rv map[string]string) error {
+	for key, val := range data {
+		name := envJoin(prefix, key)
+		switch v := val.(type) {
+		case nil:
+		case string:
+			rv[name] = v
+		case json.Number:
+			rv[name] = v.String()
+		case bool:
+			rv[name] = strconv.FormatBool(v)
+		case map[string]any:
+			if err := flattenValues(name, v, rv); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("key %s: unsupported %T value", name, val)
+		}
+	}
+	return nil
+}
+
+// MemoryProvider хранит значения в памяти, используется в тестах и как образец Provider.
+type MemoryProvider struct {
+	mu     sync.Mutex
+	values map[string]string
+	subs   []chan struct{}
+}
+
+// Проверяем, что MemoryProvider implements WatchProvider.
+var _ WatchProvider = (*MemoryProvider)(nil)
+
+// NewMemoryProvider returns MemoryProvider with copy of values.
+func NewMemoryProvider(values map[string]string) *MemoryProvider {
+	return &MemoryProvider{values: maps.Clone(values)}
+}
+
+// Set changes value and notifies watchers.
+func (p *MemoryProvider) Set(key, val string) {
+	p.mu.Lock()
+	if p.values == nil {
+		p.values = map[string]string{}
+	}
+	p.values[key] = val
+	p.mu.Unlock()
+	p.notify()
+}
+
+// Delete removes value and notifies watchers.
+func (p *MemoryProvider) Delete(key string) {
+	p.mu.Lock()
+	delete(p.values, key)
+	p.mu.Unlock()
+	p.notify()
+}
+
+// Fetch returns copy of values.
+func (p *MemoryProvider) Fetch(context.Context) (map[string]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return maps.Clone(p.values), nil
+}
+
+// Watch calls notify after each Set or Delete until ctx is done.
+func (p *MemoryProvider) Watch(ctx context.Context, notify func()) error {
+	ch := make(chan struct{}, 1)
+	p.mu.Lock()
+	p.subs = append(p.subs, ch)
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for i, sub := range p.subs {
+			if sub == ch {
+				p.subs = append(p.subs[:i], p.subs[i+1:]...)
+				break
+			}
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ch:
+			notify()
+		}
+	}
+}
+
+func (p *MemoryProvider) notify() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, ch := range p.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
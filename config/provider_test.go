@@ -0,0 +1,144 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ProviderDB struct {
+	Pool  int      `long:"pool" env:"POOL" default:"1"`
+	Hosts []string `long:"host" env:"HOSTS" env-delim:","`
+}
+
+type ProviderConfig struct {
+	EnableConfigFile
+	Level string     `long:"level" env:"PROVIDER_LEVEL" default:"info" reload:"true"`
+	Name  string     `long:"name" env:"PROVIDER_NAME"`
+	DB    ProviderDB `group:"DB" namespace:"db" env-namespace:"DB"`
+}
+
+func TestOpenWithProvider(t *testing.T) {
+	file := writeFile(t, "cfg.yaml", "level: file\nname: file\n")
+	p := NewMemoryProvider(map[string]string{
+		"PROVIDER_LEVEL": "remote",
+		"PROVIDER_NAME":  "remote",
+		"DB_POOL":        "4",
+		"DB_HOSTS":       "a,b",
+		"OTHER_APP_KEY":  "ignored",
+	})
+	t.Setenv("PROVIDER_NAME", "env")
+
+	var cfg ProviderConfig
+	err := OpenWith(&cfg, WithArgs("--config", file), WithProvider(p))
+	require.NoError(t, err)
+	assert.Equal(t, "remote", cfg.Level, "provider overrides file")
+	assert.Equal(t, "env", cfg.Name, "env overrides provider")
+	assert.Equal(t, 4, cfg.DB.Pool)
+	assert.Equal(t, []string{"a", "b"}, cfg.DB.Hosts)
+
+	err = OpenWith(&cfg, WithArgs("--config", file, "--level", "flag"), WithProvider(p))
+	require.NoError(t, err)
+	assert.Equal(t, "flag", cfg.Level)
+}
+
+type failProvider struct{}
+
+var errFetch = errors.New("unavailable")
+
+func (failProvider) Fetch(context.Context) (map[string]string, error) {
+	return nil, errFetch
+}
+
+func TestOpenWithProviderError(t *testing.T) {
+	var cfg ProviderConfig
+	err := OpenWith(&cfg, WithArgs("--level", "x"), WithProvider(failProvider{}))
+	assert.ErrorIs(t, err, errFetch)
+	assert.Equal(t, ExitBadArgs, ExitCode(err))
+
+	type S struct {
+		EnableShowVersion
+		Level string `long:"level" env:"PROVIDER_LEVEL"`
+	}
+	err = OpenWith(&S{}, WithArgs("--version"), WithProvider(failProvider{}))
+	require.ErrorIs(t, err, ErrVersion, "provider is not fetched for --version")
+	err = OpenWith(&S{}, WithArgs("-h"), WithProvider(failProvider{}))
+	require.ErrorIs(t, err, ErrHelpRequest, "provider is not fetched for help")
+	err = OpenWith(&S{}, WithArgs("--level", "x", "--", "--version"), WithProvider(failProvider{}))
+	require.ErrorIs(t, err, errFetch)
+}
+
+func TestHTTPProvider(t *testing.T) {
+	body := `{"PROVIDER_LEVEL": "warn", "DB": {"POOL": 8, "HOSTS": "h1"}, "FLAG": true, "NONE": null}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	p := NewHTTPProvider(srv.URL)
+	p.Header.Set("X-Token", "secret")
+	got, err := p.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PROVIDER_LEVEL": "warn",
+		"DB_POOL":        "8",
+		"DB_HOSTS":       "h1",
+		"FLAG":           "true",
+	}, got)
+
+	var cfg ProviderConfig
+	require.NoError(t, OpenWith(&cfg, WithArgs("--name", "x"), WithProvider(p)))
+	assert.Equal(t, 8, cfg.DB.Pool)
+
+	body = `{"LIST": [1]}`
+	_, err = p.Fetch(context.Background())
+	assert.ErrorContains(t, err, "key LIST: unsupported []interface {} value")
+}
+
+func TestHTTPProviderLiteral(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"PROVIDER_LEVEL": "warn"}`))
+	}))
+	defer srv.Close()
+
+	// нулевые Client и Interval заменяются значениями по умолчанию
+	p := &HTTPProvider{URL: srv.URL}
+	got, err := p.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"PROVIDER_LEVEL": "warn"}, got)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, p.Watch(ctx, func() {}))
+}
+
+func TestWatcherProvider(t *testing.T) {
+	p := NewMemoryProvider(map[string]string{"PROVIDER_LEVEL": "info"})
+	var cfg ProviderConfig
+	opts := []OpenOption{WithArgs("--name", "x"), WithProvider(p)}
+	require.NoError(t, OpenWith(&cfg, opts...))
+
+	done := make(chan string, 1)
+	w := NewWatcher(&cfg).WithOptions(opts...).
+		Subscribe(func(_, c ProviderConfig) { done <- c.Level })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = w.Run(ctx) }()
+	time.Sleep(30 * time.Millisecond)
+
+	p.Set("PROVIDER_LEVEL", "debug")
+	select {
+	case level := <-done:
+		assert.Equal(t, "debug", level)
+	case <-time.After(time.Second):
+		t.Fatal("provider change not detected")
+	}
+}
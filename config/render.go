@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// ErrUnknownFormat returned by WriteConfig for format without registered Renderer.
+var ErrUnknownFormat = errors.New("unknown config format")
+
+// Renderer writes config definitions in some format.
+type Renderer interface {
+	Render(w io.Writer, defs []Def) error
+}
+
+// RendererFunc allows to use ordinary function as Renderer.
+type RendererFunc func(w io.Writer, defs []Def) error
+
+// Render calls f(w, defs).
+func (f RendererFunc) Render(w io.Writer, defs []Def) error {
+	return f(w, defs)
+}
+
+var (
+	renderers = map[string]Renderer{}
+	formats   []string
+)
+
+func init() {
+	RegisterRenderer("json", RendererFunc(func(w io.Writer, defs []Def) error {
+		return writeJSON(w, defs)
+	}))
+	RegisterRenderer("jsonschema", RendererFunc(func(w io.Writer, defs []Def) error {
+		return writeJSON(w, NewJSONSchema(defs, application))
+	}))
+	RegisterRenderer("md", RendererFunc(func(w io.Writer, defs []Def) error {
+		return WriteConfigM(w, defs, false, "", "", "Main Options")
+	}))
+	RegisterRenderer("mk", RendererFunc(func(w io.Writer, defs []Def) error {
+		return WriteConfigM(w, defs, true, "", "", "Main Options")
+	}))
+	RegisterRenderer("env", RendererFunc(WriteConfigEnv))
+	RegisterRenderer("compose", RendererFunc(WriteConfigCompose))
+	RegisterRenderer("k8s", RendererFunc(func(w io.Writer, defs []Def) error {
+		return WriteConfigK8s(w, defs, application)
+	}))
+}
+
+// RegisterRenderer adds (or replaces) Renderer for given format, which becomes
+// available in WriteConfig and as `--config_gen` value.
+// It is not safe for concurrent use and should be called from init or before config.Open.
+func RegisterRenderer(format string, r Renderer) {
+	if format == "" {
+		panic("config: empty renderer format")
+	}
+	if _, ok := renderers[format]; !ok {
+		formats = append(formats, format)
+	}
+	renderers[format] = r
+}
+
+// Formats returns registered format names in order of registration.
+func Formats() []string {
+	return slices.Clone(formats)
+}
+
+func lookupRenderer(format string) (Renderer, bool) {
+	r, ok := renderers[format]
+	return r, ok
+}
+
+// setGenFormats allows all registered formats as `--config_gen` values.
+func setGenFormats(idx map[string]*flags.Option) {
+	for _, opt := range idx {
+		if opt.Field().Name == "GoKitConfigDefGenOption" {
+			opt.Choices = append([]string{""}, formats...)
+		}
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	val, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(val))
+	return err
+}
+
+// errWriter keeps first write error, so formatted output may be written without checks.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, a ...any) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, a...)
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type RenderConfig struct {
+	EnableConfigDefGen
+	Root string `long:"root" env:"ROOT" default:"/var/www" description:"Root dir"`
+}
+
+func TestWriteConfig(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteConfig(&buf, RenderConfig{}, "mk"))
+	assert.Regexp(t, `ROOT +\?= /var/www`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteConfig(&buf, RenderConfig{}, "json"))
+	assert.Contains(t, buf.String(), `"name": "root"`)
+
+	err := WriteConfig(&buf, RenderConfig{}, "xml")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestWriteConfigError(t *testing.T) {
+	for _, format := range Formats() {
+		err := WriteConfig(failWriter{}, RenderConfig{}, format)
+		assert.ErrorIs(t, err, io.ErrClosedPipe, format)
+	}
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	errRender := errors.New("render failed")
+	RegisterRenderer("test", RendererFunc(func(w io.Writer, defs []Def) error {
+		return errRender
+	}))
+	t.Cleanup(func() {
+		delete(renderers, "test")
+		formats = formats[:len(formats)-1]
+	})
+	assert.Contains(t, Formats(), "test")
+
+	var cfg RenderConfig
+	err := Open(&cfg, "--config_gen", "test")
+	assert.ErrorIs(t, err, errRender)
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// JSONSchemaDraft - версия спецификации JSON Schema.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern соответствует значениям, которые принимает time.ParseDuration.
+const durationPattern = `^[-+]?(0|([0-9]*(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+)$`
+
+// JSONSchema - описание конфигурации в формате JSON Schema.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	WriteOnly            bool                   `json:"writeOnly,omitempty"`
+	Deprecated           bool                   `json:"deprecated,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+}
+
+// NewJSONSchema returns JSON Schema (draft 2020-12) of config file described by defs.
+// Groups are nested objects named by namespace, as `--config` expects.
+func NewJSONSchema(defs []Def, title string) *JSONSchema {
+	rv := schemaObject(defs)
+	rv.Schema = JSONSchemaDraft
+	rv.Title = title
+	return rv
+}
+
+// schemaObject returns object schema for group items.
+func schemaObject(defs []Def) *JSONSchema {
+	rv := &JSONSchema{
+		Type:                 "object",
+		Properties:           map[string]*JSONSchema{},
+		AdditionalProperties: false,
+	}
+	schemaProperties(rv, defs)
+	return rv
+}
+
+func schemaProperties(obj *JSONSchema, defs []Def) {
+	for _, def := range defs {
+		if def.IsGroup || def.IsCommand {
+			if def.Name == "" || def.IsCommand {
+				// группа без namespace и команда не меняют имена параметров
+				schemaProperties(obj, def.Group.Items)
+				continue
+			}
+			child := schemaObject(def.Group.Items)
+			child.Description = def.Description
+			obj.Properties[def.Name] = child
+			continue
+		}
+		if def.Name == "" {
+			continue
+		}
+		obj.Properties[def.Name] = schemaItem(def)
+	}
+}
+
+// schemaItem returns schema of config option.
+func schemaItem(def Def) *JSONSchema {
+	item := def.Item
+	rv := schemaType(item.Type)
+	rv.Description = def.Description
+	rv.Deprecated = item.Deprecated != ""
+	scalar := rv
+	if rv.Items != nil {
+		scalar = rv.Items
+	}
+	for _, opt := range item.Options {
+		scalar.Enum = append(scalar.Enum, schemaValue(scalar.Type, opt))
+	}
+	if item.Secret {
+		rv.WriteOnly = true
+	} else if item.Default != "" {
+		if rv.Items != nil {
+			rv.Default = []any{schemaValue(scalar.Type, item.Default)}
+		} else if rv.Type != "object" {
+			rv.Default = schemaValue(rv.Type, item.Default)
+		}
+	}
+	return rv
+}
+
+// schemaType returns schema for Go type name from ItemDef.Type.
+func schemaType(typ string) *JSONSchema {
+	switch {
+	case strings.HasPrefix(typ, "[]"):
+		return &JSONSchema{Type: "array", Items: schemaType(typ[2:])}
+	case strings.HasPrefix(typ, "map["):
+		_, elem, _ := strings.Cut(typ, "]")
+		return &JSONSchema{Type: "object", AdditionalProperties: schemaType(elem)}
+	case typ == "bool":
+		return &JSONSchema{Type: "boolean"}
+	case typ == "time.Duration":
+		// в файле конфигурации число задает наносекунды
+		return &JSONSchema{OneOf: []*JSONSchema{{Type: "string", Pattern: durationPattern}, {Type: "integer"}}}
+	case typ == "string":
+		return &JSONSchema{Type: "string"}
+	case strings.HasPrefix(typ, "int"), strings.HasPrefix(typ, "uint"):
+		return &JSONSchema{Type: "integer"}
+	case strings.HasPrefix(typ, "float"):
+		return &JSONSchema{Type: "number"}
+	}
+	// custom types (flags.Unmarshaler etc) are set from string
+	return &JSONSchema{Type: "string"}
+}
+
+// schemaValue converts tag value to JSON value of given type.
+func schemaValue(typ, val string) any {
+	switch typ {
+	case "boolean":
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	case "integer":
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(val, 64); err == nil {
+			return n
+		}
+	}
+	return val
+}
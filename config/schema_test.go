@@ -0,0 +1,128 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type SchemaLog struct {
+	Debug  bool   `long:"debug" description:"Show debug info"`
+	Format string `long:"format" choice:"text" choice:"json" default:"text" description:"Output format"`
+}
+
+type SchemaConfig struct {
+	Root   string            `long:"root" default:"." description:"Root dir"`
+	Port   int               `long:"port" default:"8080"`
+	Grace  time.Duration     `long:"grace" default:"10s"`
+	Tags   []string          `long:"tag" default:"a"`
+	Labels map[string]string `long:"label"`
+	Pass   string            `long:"pass" default:"x" secret:"true"`
+	Log    SchemaLog         `group:"Logging" namespace:"log"`
+	Audit  *SchemaLog        `group:"Audit" namespace:"audit"`
+	Extra  struct {
+		Ratio float64 `long:"ratio" default:"0.5"`
+	} `group:"Extra"`
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema := NewJSONSchema(FetchDefs(SchemaConfig{}), "app")
+	got, err := json.MarshalIndent(schema, "", "  ")
+	require.NoError(t, err)
+	want := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "app",
+  "type": "object",
+  "properties": {
+    "audit": {
+      "description": "Audit",
+      "type": "object",
+      "properties": {
+        "debug": {
+          "description": "Show debug info",
+          "type": "boolean"
+        },
+        "format": {
+          "description": "Output format",
+          "type": "string",
+          "enum": [
+            "text",
+            "json"
+          ],
+          "default": "text"
+        }
+      },
+      "additionalProperties": false
+    },
+    "grace": {
+      "default": "10s",
+      "oneOf": [
+        {
+          "type": "string",
+          "pattern": "^[-+]?(0|([0-9]*(\\.[0-9]*)?(ns|us|µs|ms|s|m|h))+)$"
+        },
+        {
+          "type": "integer"
+        }
+      ]
+    },
+    "label": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "string"
+      }
+    },
+    "log": {
+      "description": "Logging",
+      "type": "object",
+      "properties": {
+        "debug": {
+          "description": "Show debug info",
+          "type": "boolean"
+        },
+        "format": {
+          "description": "Output format",
+          "type": "string",
+          "enum": [
+            "text",
+            "json"
+          ],
+          "default": "text"
+        }
+      },
+      "additionalProperties": false
+    },
+    "pass": {
+      "type": "string",
+      "writeOnly": true
+    },
+    "port": {
+      "type": "integer",
+      "default": 8080
+    },
+    "ratio": {
+      "type": "number",
+      "default": 0.5
+    },
+    "root": {
+      "description": "Root dir",
+      "type": "string",
+      "default": "."
+    },
+    "tag": {
+      "type": "array",
+      "default": [
+        "a"
+      ],
+      "items": {
+        "type": "string"
+      }
+    }
+  },
+  "additionalProperties": false
+}`
+	assert.Equal(t, want, string(got))
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// SecretMask replaces values of fields tagged with `secret:"true"`.
+const SecretMask = "******"
+
+// SecretFileSuffix is added to ENV name of secret field for loading its value from file,
+// e.g. `DB_PASSWORD_FILE=/run/secrets/db_password`.
+const SecretFileSuffix = "_FILE"
+
+func isSecret(tag reflect.StructTag) bool {
+	return tag.Get("secret") == "true"
+}
+
+// Redact returns a copy of struct (or pointer to struct) v where non-empty
+// fields tagged with `secret:"true"` are replaced by SecretMask
+// (string values) or zero values (other types), including structs nested in
+// pointers, slices and maps. Original v is not changed.
+func Redact(v any) any {
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct:
+		cp := reflect.New(rv.Elem().Type())
+		cp.Elem().Set(rv.Elem())
+		redactStruct(cp.Elem())
+		return cp.Interface()
+	case rv.Kind() == reflect.Struct:
+		cp := reflect.New(rv.Type()).Elem()
+		cp.Set(rv)
+		redactStruct(cp)
+		return cp.Interface()
+	}
+	return v
+}
+
+// redactStruct masks secret fields of addressable struct v.
+// Nested pointers, slices and maps holding structs are copied before changes.
+func redactStruct(v reflect.Value) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		fv := v.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+		if isSecret(field.Tag) {
+			fv.Set(maskedValue(fv))
+			continue
+		}
+		switch {
+		case fv.Kind() == reflect.Struct:
+			redactStruct(fv)
+		case holdsStruct(fv.Type()):
+			fv.Set(redactCopy(fv))
+		}
+	}
+}
+
+// holdsStruct returns true if values of type t may contain structs.
+func holdsStruct(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return holdsStruct(t.Elem())
+	}
+	return false
+}
+
+// redactCopy returns copy of v with masked secrets of nested structs.
+func redactCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Struct:
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		redactStruct(cp)
+		return cp
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.New(v.Elem().Type())
+		cp.Elem().Set(redactCopy(v.Elem()))
+		return cp
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			cp.Index(i).Set(redactCopy(v.Index(i)))
+		}
+		return cp
+	case reflect.Array:
+		cp := reflect.New(v.Type()).Elem()
+		for i := range v.Len() {
+			cp.Index(i).Set(redactCopy(v.Index(i)))
+		}
+		return cp
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), redactCopy(iter.Value()))
+		}
+		return cp
+	}
+	return v
+}
+
+// maskedValue returns new value with masked secret.
+func maskedValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.String:
+		if v.Len() > 0 {
+			return reflect.ValueOf(SecretMask).Convert(v.Type())
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String {
+			rv := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+			for i := range v.Len() {
+				rv.Index(i).Set(maskedValue(v.Index(i)))
+			}
+			return rv
+		}
+		return reflect.Zero(v.Type())
+	default:
+		return reflect.Zero(v.Type())
+	}
+	return v
+}
+
+type masked struct {
+	value any
+}
+
+// LogValue implements slog.LogValuer.
+func (m masked) LogValue() slog.Value {
+	return slog.AnyValue(Redact(m.value))
+}
+
+// Masked returns slog.LogValuer which logs v with redacted secrets.
+//
+//	slog.Info("Config", "cfg", config.Masked(cfg))
+func Masked(v any) slog.LogValuer {
+	return masked{v}
+}
+
+// secretFiles loads secret option values from files given in ENV with SecretFileSuffix.
+// ENV with value itself has priority.
+func secretFiles(idx map[string]*flags.Option, lookupEnv func(string) (string, bool),
+	values map[string][]string, sources map[string]string,
+) error {
+	for name, opt := range idx {
+		key := opt.EnvKeyWithNamespace()
+		if key == "" || !isSecret(opt.Field().Tag) {
+			continue
+		}
+		if _, ok := lookupEnv(key); ok {
+			continue
+		}
+		file, ok := lookupEnv(key + SecretFileSuffix)
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(file) //nolint:gosec
+		if err != nil {
+			return fmt.Errorf("secret %s: %w", key, err)
+		}
+		values[name] = []string{strings.TrimRight(string(data), "\r\n")}
+		sources[name] = SourceEnv + " " + key + SecretFileSuffix
+	}
+	return nil
+}
+
+// maskSecrets hides secret defaults in help output.
+func maskSecrets(idx map[string]*flags.Option) {
+	for _, opt := range idx {
+		if len(opt.Default) > 0 && isSecret(opt.Field().Tag) {
+			opt.DefaultMask = SecretMask
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type SecretDB struct {
+	DSN  string `long:"dsn" env:"DSN" secret:"true" default:"postgres://u:p@localhost/db"`
+	Pool int    `long:"pool" default:"4"`
+}
+
+type SecretConfig struct {
+	Token string    `long:"token" env:"TOKEN" secret:"true"`
+	Keys  []string  `long:"key" secret:"true"`
+	DB    SecretDB  `group:"DB" namespace:"db" env-namespace:"DB"`
+	Ptr   *SecretDB `group:"Ptr" namespace:"ptr"`
+	EnableConfigDump
+	EnableConfigLoad
+}
+
+func TestRedact(t *testing.T) {
+	cfg := SecretConfig{
+		Token: "t0ken",
+		Keys:  []string{"k1", ""},
+		DB:    SecretDB{DSN: "dsn", Pool: 2},
+		Ptr:   &SecretDB{DSN: "ptr"},
+	}
+	got := Redact(&cfg).(*SecretConfig)
+	assert.Equal(t, SecretMask, got.Token)
+	assert.Equal(t, []string{SecretMask, ""}, got.Keys)
+	assert.Equal(t, SecretMask, got.DB.DSN)
+	assert.Equal(t, 2, got.DB.Pool)
+	assert.Equal(t, SecretMask, got.Ptr.DSN)
+
+	assert.Equal(t, "t0ken", cfg.Token, "original not changed")
+	assert.Equal(t, "k1", cfg.Keys[0], "original not changed")
+	assert.Equal(t, "ptr", cfg.Ptr.DSN, "original not changed")
+
+	assert.Equal(t, "", Redact(SecretConfig{}).(SecretConfig).Token, "empty value is not masked")
+
+	type Cluster struct {
+		Replicas []SecretDB           `no-flag:"true"`
+		Named    map[string]*SecretDB `no-flag:"true"`
+		Pairs    [1]SecretDB          `no-flag:"true"`
+	}
+	cluster := Cluster{
+		Replicas: []SecretDB{{DSN: "r1", Pool: 1}},
+		Named:    map[string]*SecretDB{"a": {DSN: "a"}, "nil": nil},
+		Pairs:    [1]SecretDB{{DSN: "p"}},
+	}
+	gotCluster := Redact(cluster).(Cluster)
+	assert.Equal(t, []SecretDB{{DSN: SecretMask, Pool: 1}}, gotCluster.Replicas)
+	assert.Equal(t, SecretMask, gotCluster.Named["a"].DSN)
+	assert.Nil(t, gotCluster.Named["nil"])
+	assert.Equal(t, SecretMask, gotCluster.Pairs[0].DSN)
+	assert.Equal(t, "r1", cluster.Replicas[0].DSN, "original not changed")
+	assert.Equal(t, "a", cluster.Named["a"].DSN, "original not changed")
+}
+
+func TestSecretDumpLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "dump.json")
+	cfg := &SecretConfig{}
+	require.NoError(t, Open(cfg, "--token", "t0ken", "--config_dump", file))
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "t0ken")
+	assert.NotContains(t, string(data), "u:p@")
+
+	cfg = &SecretConfig{}
+	require.NoError(t, Open(cfg, "--config_load", file))
+	assert.Equal(t, "", cfg.Token, "masked secret is not loaded")
+}
+
+func TestSecretFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "dsn")
+	require.NoError(t, os.WriteFile(file, []byte("postgres://file\n"), 0o600))
+	t.Setenv("DB_DSN_FILE", file)
+
+	cfg := &SecretConfig{}
+	require.NoError(t, Open(cfg, "--db.pool", "1"))
+	assert.Equal(t, "postgres://file", cfg.DB.DSN)
+
+	t.Setenv("DB_DSN", "postgres://env")
+	cfg = &SecretConfig{}
+	require.NoError(t, Open(cfg, "--db.pool", "1"))
+	assert.Equal(t, "postgres://env", cfg.DB.DSN, "env wins over file")
+
+	t.Setenv("TOKEN_FILE", "/not/exists")
+	err := Open(&SecretConfig{}, "--db.pool", "1")
+	require.ErrorAs(t, err, &ErrBadArgsContainer{})
+}
+
+func TestSecretDefs(t *testing.T) {
+	defs := FetchDefs(SecretConfig{})
+	require.Len(t, defs, 6)
+	dsn := defs[2].Group.Items[0]
+	assert.True(t, dsn.Item.Secret)
+	assert.Equal(t, SecretMask, dsn.Item.Default)
+	assert.Equal(t, "ptr", defs[3].Name, "nil pointer group is described")
+	assert.True(t, defs[3].Group.Items[0].Item.Secret)
+}
+
+func TestMasked(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	log.Info("Config", "cfg", Masked(SecretConfig{Token: "t0ken"}))
+	var rec struct {
+		Cfg SecretConfig `json:"cfg"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	assert.Equal(t, SecretMask, rec.Cfg.Token)
+}
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unixPrefix is a prefix of unix socket address accepted by hostport rule.
+const unixPrefix = "unix:"
+
+// Validate checks cfg fields against their `validate:"..."` tags and returns
+// all found problems joined in one error.
+//
+// Tag holds comma separated rules:
+//   - min=N, max=N - value range for numbers and durations (`min=1s`), length for strings, slices and maps;
+//   - oneof=a b c - allowed values;
+//   - url - absolute URL;
+//   - hostport - `host:port` address or unix socket (`unix:/path/to.sock`);
+//   - file-exists - path to existing file or directory;
+//   - regexp=RE - value must match RE, this rule must be the last one.
+//
+// Format rules (oneof, url, hostport, file-exists, regexp) skip zero values (e.g. empty strings),
+// use `min=1` to require a value.
+func Validate(cfg any) error {
+	v := reflect.ValueOf(cfg)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return errors.Join(validateStruct(v, "", nil)...)
+}
+
+// validate checks cfg like Validate, but skips fields of commands which are not active.
+func validate(cfg any, active map[string]bool) error {
+	v := reflect.Indirect(reflect.ValueOf(cfg))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return errors.Join(validateStruct(v, "", active)...)
+}
+
+// validateStruct checks fields of struct v. If active is not nil, only commands from it are checked.
+func validateStruct(v reflect.Value, prefix string, active map[string]bool) []error {
+	var errs []error
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		if name := field.Tag.Get("command"); name != "" && active != nil && !active[name] {
+			continue
+		}
+		fv := v.Field(i)
+		long := field.Tag.Get("long")
+		if long == "" {
+			for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				np := prefix
+				if ns := field.Tag.Get("namespace"); ns != "" && field.Tag.Get("group") != "" {
+					np = prefix + ns + "."
+				}
+				errs = append(errs, validateStruct(fv, np, active)...)
+				continue
+			}
+			long = field.Name
+		}
+		tag := field.Tag.Get("validate")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+		for _, err := range validateField(fv, tag) {
+			errs = append(errs, fmt.Errorf("option --%s%s: %w", prefix, long, err))
+		}
+	}
+	return errs
+}
+
+// validateField checks value against all rules from tag.
+func validateField(v reflect.Value, tag string) []error {
+	var errs []error
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "regexp=") {
+			rule, tag = tag, ""
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if err := validateRule(v, name, arg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func validateRule(v reflect.Value, name, arg string) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch name {
+	case "min", "max":
+		return validateRange(v, name, arg)
+	case "oneof", "url", "hostport", "file-exists", "regexp":
+		check, err := formatCheck(name, arg)
+		if err != nil {
+			return err
+		}
+		if v.Kind() == reflect.Slice {
+			for i := range v.Len() {
+				if err := checkString(v.Index(i), check); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return checkString(v, check)
+	}
+	return fmt.Errorf("unknown validate rule %q", name)
+}
+
+func checkString(v reflect.Value, check func(string) error) error {
+	if v.IsZero() {
+		return nil
+	}
+	return check(fmt.Sprint(v))
+}
+
+// formatCheck returns check func for format rules.
+func formatCheck(name, arg string) (func(string) error, error) {
+	switch name {
+	case "oneof":
+		allowed := strings.Fields(arg)
+		return func(s string) error {
+			if !slices.Contains(allowed, s) {
+				return fmt.Errorf("value %q must be one of %s", s, strings.Join(allowed, ", "))
+			}
+			return nil
+		}, nil
+	case "url":
+		return func(s string) error {
+			u, err := url.Parse(s)
+			if err != nil {
+				return err
+			}
+			if u.Scheme == "" || u.Host == "" {
+				return fmt.Errorf("value %q must be an absolute URL", s)
+			}
+			return nil
+		}, nil
+	case "hostport":
+		return func(s string) error {
+			if path, ok := strings.CutPrefix(s, unixPrefix); ok {
+				if path == "" {
+					return fmt.Errorf("value %q has empty socket path", s)
+				}
+				return nil
+			}
+			_, port, err := net.SplitHostPort(s)
+			if err != nil {
+				return err
+			}
+			if port == "" {
+				// LookupPort accepts empty port as 0
+				return fmt.Errorf("value %q has empty port", s)
+			}
+			if _, err := net.LookupPort("tcp", port); err != nil {
+				return fmt.Errorf("value %q has invalid port: %w", s, err)
+			}
+			return nil
+		}, nil
+	case "file-exists":
+		return func(s string) error {
+			_, err := os.Stat(s)
+			return err
+		}, nil
+	case "regexp":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validate rule regexp: %w", err)
+		}
+		return func(s string) error {
+			if !re.MatchString(s) {
+				return fmt.Errorf("value %q must match %s", s, arg)
+			}
+			return nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown validate rule %q", name)
+}
+
+// validateRange checks min/max of numbers, durations and lengths.
+func validateRange(v reflect.Value, name, arg string) error {
+	var c int
+	var got string
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		limit, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("invalid validate rule %s=%s: %w", name, arg, err)
+		}
+		c = cmp.Compare(v.Len(), limit)
+		if c != 0 && (c < 0) == (name == "min") {
+			return fmt.Errorf("length must be %s %d (got %d)", rangeOp(name), limit, v.Len())
+		}
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			limit, err := time.ParseDuration(arg)
+			if err != nil {
+				return fmt.Errorf("invalid validate rule %s=%s: %w", name, arg, err)
+			}
+			c, got = cmp.Compare(time.Duration(v.Int()), limit), time.Duration(v.Int()).String()
+		} else {
+			limit, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid validate rule %s=%s: %w", name, arg, err)
+			}
+			c, got = cmp.Compare(v.Int(), limit), strconv.FormatInt(v.Int(), 10)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		limit, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid validate rule %s=%s: %w", name, arg, err)
+		}
+		c, got = cmp.Compare(v.Uint(), limit), strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("invalid validate rule %s=%s: %w", name, arg, err)
+		}
+		c, got = cmp.Compare(v.Float(), limit), strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return fmt.Errorf("validate rule %s is not supported for %s", name, v.Type())
+	}
+	if c != 0 && (c < 0) == (name == "min") {
+		return fmt.Errorf("value must be %s %s (got %s)", rangeOp(name), arg, got)
+	}
+	return nil
+}
+
+func rangeOp(name string) string {
+	if name == "min" {
+		return ">="
+	}
+	return "<="
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ValidateGroup struct {
+	Listen string        `long:"listen" validate:"hostport"`
+	Grace  time.Duration `long:"grace" validate:"min=0s,max=1m"`
+}
+
+type ValidateConfig struct {
+	Name    string        `long:"name" validate:"min=1,regexp=^[a-z,]+$"`
+	Workers int           `long:"workers" validate:"min=1,max=8"`
+	Ratio   float64       `long:"ratio" validate:"max=1"`
+	Mode    string        `long:"mode" validate:"oneof=fast slow"`
+	Tags    []string      `long:"tag" validate:"max=2,oneof=a b c"`
+	URL     string        `long:"url" validate:"url"`
+	File    string        `long:"file" validate:"file-exists"`
+	Srv     ValidateGroup `group:"Server" namespace:"srv"`
+}
+
+func TestValidate(t *testing.T) {
+	valid := ValidateConfig{
+		Name:    "a,b",
+		Workers: 2,
+		Mode:    "fast",
+		Tags:    []string{"a", "c"},
+		URL:     "http://localhost:4317",
+		File:    ".",
+		Srv:     ValidateGroup{Listen: ":8080", Grace: time.Second},
+	}
+	require.NoError(t, Validate(&valid))
+
+	type Level struct {
+		Level int `long:"level" validate:"oneof=1 2"`
+	}
+	require.NoError(t, Validate(Level{}), "zero value is not checked")
+	assert.EqualError(t, Validate(Level{Level: 3}), `option --level: value "3" must be one of 1, 2`)
+
+	err := Validate(ValidateConfig{
+		Workers: 9,
+		Ratio:   1.5,
+		Mode:    "none",
+		Tags:    []string{"a", "b", "x"},
+		URL:     "localhost",
+		File:    "/not/exists",
+		Srv:     ValidateGroup{Listen: "localhost", Grace: -time.Second},
+	})
+	require.Error(t, err)
+	want := `option --name: length must be >= 1 (got 0)
+option --workers: value must be <= 8 (got 9)
+option --ratio: value must be <= 1 (got 1.5)
+option --mode: value "none" must be one of fast, slow
+option --tag: length must be <= 2 (got 3)
+option --tag: value "x" must be one of a, b, c
+option --url: value "localhost" must be an absolute URL
+option --file: stat /not/exists: no such file or directory
+option --srv.listen: address localhost: missing port in address
+option --srv.grace: value must be >= 0s (got -1s)`
+	assert.Equal(t, want, err.Error())
+}
+
+func TestValidateBadTag(t *testing.T) {
+	type S struct {
+		A int    `long:"a" validate:"min=x"`
+		B string `long:"b" validate:"between=1"`
+		C bool   `long:"c" validate:"max=1"`
+	}
+	err := Validate(S{})
+	require.Error(t, err)
+	want := `option --a: invalid validate rule min=x: strconv.ParseInt: parsing "x": invalid syntax
+option --b: unknown validate rule "between"
+option --c: validate rule max is not supported for bool`
+	assert.Equal(t, want, err.Error())
+}
+
+func TestOpenValidate(t *testing.T) {
+	type S struct {
+		Listen string `long:"listen" default:":8080" validate:"hostport"`
+		EnableShowVersion
+		EnableConfigDump
+	}
+	err := Open(&S{}, "--listen", "bad")
+	require.ErrorAs(t, err, &ErrBadArgsContainer{})
+
+	var code int
+	Close(err, func(c int) { code = c })
+	assert.Equal(t, ExitBadArgs, code)
+
+	require.NoError(t, Open(&S{}, "--listen", "localhost:http"))
+	require.NoError(t, Open(&S{}, "--listen", "unix:/run/app.sock"))
+	require.ErrorAs(t, Open(&S{}, "--listen", "unix:"), &ErrBadArgsContainer{})
+	for _, addr := range []string{"localhost:", ":"} {
+		err = Validate(S{Listen: addr})
+		assert.EqualError(t, err, `option --listen: value "`+addr+`" has empty port`)
+	}
+	assert.ErrorIs(t, Open(&S{}, "--listen", "bad", "--version"), ErrVersion, "version works with invalid config")
+
+	dump := filepath.Join(t.TempDir(), "dump.yaml")
+	require.ErrorAs(t, Open(&S{}, "--listen", "bad", "--config_dump", dump), &ErrBadArgsContainer{})
+	assert.NoFileExists(t, dump, "invalid config is not dumped")
+}
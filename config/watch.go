@@ -0,0 +1,209 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// WatchInterval is the default period of config file change check.
+const WatchInterval = 5 * time.Second
+
+// Watcher reloads config on SIGHUP or when config file changes.
+// Only fields tagged with `reload:"true"` (or nested in such group) are applied,
+// changes of other fields are logged and ignored until restart.
+type Watcher[T any] struct {
+	mu       sync.RWMutex
+	current  T
+	args     []string
+	opts     []OpenOption
+	interval time.Duration
+	subs     []func(old, cur T)
+}
+
+// NewWatcher returns watcher for cfg loaded by Open(cfg, args...).
+// Use WithOptions for cfg loaded by OpenWith.
+func NewWatcher[T any](cfg *T, args ...string) *Watcher[T] {
+	return &Watcher[T]{
+		current:  *cfg,
+		args:     args,
+		interval: WatchInterval,
+	}
+}
+
+// WithInterval sets config file check period, zero or negative value disables the check.
+func (w *Watcher[T]) WithInterval(interval time.Duration) *Watcher[T] {
+	w.interval = interval
+	return w
+}
+
+// WithOptions sets OpenWith options used on reload.
+// Changes of values from WatchProvider also cause reload.
+func (w *Watcher[T]) WithOptions(opts ...OpenOption) *Watcher[T] {
+	w.opts = opts
+	return w
+}
+
+// Subscribe registers func called with old and new config after reload.
+func (w *Watcher[T]) Subscribe(fn func(old, cur T)) *Watcher[T] {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subs = append(w.subs, fn)
+	return w
+}
+
+// Config returns current config.
+func (w *Watcher[T]) Config() T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Reload loads config and notifies subscribers if reloadable fields were changed.
+// On error current config is kept. Commands are not executed on reload.
+func (w *Watcher[T]) Reload() error {
+	var next T
+	if _, _, err := parse(&next, w.options()); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	old := w.current
+	cur := old
+	changed := applyReloadable(reflect.ValueOf(&cur).Elem(), reflect.ValueOf(next), "")
+	w.current = cur
+	subs := slices.Clone(w.subs)
+	w.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+	slog.Info("Config reloaded")
+	for _, fn := range subs {
+		fn(old, cur)
+	}
+	return nil
+}
+
+// Run reloads config on SIGHUP, config file or provider values change until ctx is done.
+// It has server.Worker signature.
+func (w *Watcher[T]) Run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	changes := make(chan struct{}, 1)
+	for _, p := range w.options().providers {
+		if wp, ok := p.(WatchProvider); ok {
+			go func() {
+				err := wp.Watch(ctx, func() {
+					select {
+					case changes <- struct{}{}:
+					default:
+					}
+				})
+				if err != nil {
+					slog.Error("Config provider watch", "err", err)
+				}
+			}()
+		}
+	}
+
+	var tick <-chan time.Time
+	if w.interval > 0 {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	stamps := w.fileStamps()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			slog.Debug("Config reload requested by signal")
+		case <-changes:
+			slog.Debug("Config provider values changed")
+		case <-tick:
+			next := w.fileStamps()
+			if slices.Equal(next, stamps) {
+				continue
+			}
+			stamps = next
+			slog.Debug("Config file changed")
+		}
+		if err := w.Reload(); err != nil {
+			slog.Error("Config reload", "err", err)
+		}
+	}
+}
+
+func (w *Watcher[T]) options() openOptions {
+	var opts []OpenOption
+	if len(w.args) > 0 {
+		opts = append(opts, WithArgs(w.args...))
+	}
+	rv := newOpenOptions(append(opts, w.opts...))
+	rv.reload = true
+	return rv
+}
+
+// fileStamps returns modification stamps of config files.
+func (w *Watcher[T]) fileStamps() []string {
+	cfg := w.Config()
+	var names []string
+	if v, ok := any(&cfg).(IsConfigFileRequested); ok {
+		name := v.GoKitConfigFileRequested()
+		names = append(names, name)
+		if p, ok := any(&cfg).(IsProfileRequested); ok && name != "" && p.GoKitConfigProfile() != "" {
+			names = append(names, profileFile(name, p.GoKitConfigProfile()))
+		}
+	}
+	if v, ok := any(&cfg).(IsLoadRequested); ok {
+		names = append(names, v.GoKitConfigLoadRequested())
+	}
+	rv := make([]string, 0, len(names))
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		var stamp string
+		if fi, err := os.Stat(name); err == nil {
+			stamp = fmt.Sprintf("%s/%d", fi.ModTime(), fi.Size())
+		}
+		rv = append(rv, stamp)
+	}
+	return rv
+}
+
+// applyReloadable copies changed reloadable fields from src to dst and
+// warns about other changed fields. It returns true if dst was changed.
+func applyReloadable(dst, src reflect.Value, prefix string) bool {
+	var changed bool
+	t := src.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		df, sf := dst.Field(i), src.Field(i)
+		if !df.CanSet() || reflect.DeepEqual(df.Interface(), sf.Interface()) {
+			continue
+		}
+		ok := field.Tag.Get("reload") == "true"
+		if field.Type.Kind() == reflect.Struct && field.Tag.Get("long") == "" && !ok {
+			changed = applyReloadable(df, sf, groupPrefix(field, prefix)) || changed
+			continue
+		}
+		if !ok {
+			slog.Warn("Config field changed but is not reloadable, restart required", "field", prefix+optionName(field))
+			continue
+		}
+		df.Set(sf)
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type WatchLogConfig struct {
+	Debug bool   `long:"debug" reload:"true"`
+	Dest  string `long:"dest"`
+}
+
+type WatchConfig struct {
+	Listen string         `long:"listen" default:":8080"`
+	Level  string         `long:"level" default:"info" reload:"true"`
+	Log    WatchLogConfig `group:"Log" namespace:"log"`
+	Limits struct {
+		Max int `long:"max"`
+	} `group:"Limits" namespace:"limits" reload:"true"`
+	EnableConfigFile
+}
+
+func TestWatcherReload(t *testing.T) {
+	file := writeFile(t, "cfg.yaml", "level: info\n")
+	var cfg WatchConfig
+	require.NoError(t, Open(&cfg, "--config", file))
+
+	var calls int
+	var old, cur WatchConfig
+	w := NewWatcher(&cfg, "--config", file).Subscribe(func(o, c WatchConfig) {
+		calls++
+		old, cur = o, c
+	})
+
+	require.NoError(t, w.Reload())
+	assert.Equal(t, 0, calls, "no changes - no notifications")
+
+	require.NoError(t, os.WriteFile(file, []byte("level: debug\nlisten: ':9090'\nlog: {debug: true, dest: x}\nlimits: {max: 3}\n"), 0o600))
+	require.NoError(t, w.Reload())
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "info", old.Level)
+	assert.Equal(t, "debug", cur.Level)
+	assert.True(t, cur.Log.Debug)
+	assert.Equal(t, 3, cur.Limits.Max, "group tag applies to nested fields")
+	assert.Equal(t, ":8080", cur.Listen, "not reloadable")
+	assert.Equal(t, "", cur.Log.Dest, "not reloadable")
+	assert.Equal(t, cur, w.Config())
+
+	require.NoError(t, os.WriteFile(file, []byte("level: [bad]\n"), 0o600))
+	require.Error(t, w.Reload())
+	assert.Equal(t, "debug", w.Config().Level, "config kept on error")
+}
+
+func TestWatcherRun(t *testing.T) {
+	file := writeFile(t, "cfg.yaml", "level: info\n")
+	var cfg WatchConfig
+	require.NoError(t, Open(&cfg, "--config", file))
+
+	done := make(chan string, 1)
+	w := NewWatcher(&cfg, "--config", file).
+		WithInterval(10 * time.Millisecond).
+		Subscribe(func(_, c WatchConfig) { done <- c.Level })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = w.Run(ctx) }()
+	time.Sleep(30 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(file, []byte("level: warn\n"), 0o600))
+	select {
+	case level := <-done:
+		assert.Equal(t, "warn", level)
+	case <-time.After(time.Second):
+		t.Fatal("config change not detected")
+	}
+}
+
+func TestWatcherReloadSkipsProcessOptions(t *testing.T) {
+	dump := filepath.Join(t.TempDir(), "dump.json")
+	var cfg struct {
+		WatchConfig
+		EnableConfigDump
+	}
+	require.NoError(t, Open(&cfg, "--config_dump", dump))
+	require.FileExists(t, dump)
+	require.NoError(t, os.Remove(dump))
+
+	w := NewWatcher(&cfg, "--config_dump", dump)
+	require.NoError(t, w.Reload())
+	assert.NoFileExists(t, dump, "config_dump is not repeated on reload")
+}
+
+func TestWatcherRunNoInterval(t *testing.T) {
+	var cfg WatchConfig
+	require.NoError(t, OpenWith(&cfg, WithArgs()))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- NewWatcher(&cfg).WithInterval(0).Run(ctx) }()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"os"
 
@@ -14,10 +13,9 @@ import (
 
 // Config holds all config vars.
 type Config struct {
-	Mode string `long:"mode" default:"server" choice:"server" choice:"client" description:"Example mode: server or client" env:"MODE"`
+	Server ServerCommand `command:"server" description:"Run HTTP server"`
+	Client ClientCommand `command:"client" description:"Send request to HTTP server"`
 
-	Server        server.Config        `group:"HTTP Options" namespace:"http" env-namespace:"HTTP"`
-	Client        ClientConfig         `group:"Client Options" namespace:"client" env-namespace:"CLIENT"`
 	Logger        slogger.Config       `group:"Logging Options" namespace:"log" env-namespace:"LOG"`
 	Observability observability.Config `group:"OpenTelemetry Options" namespace:"otel" env-namespace:"OTEL"`
 
@@ -26,6 +24,32 @@ type Config struct {
 	config.EnableConfigDump
 }
 
+// ServerCommand holds config vars of `server` command.
+type ServerCommand struct {
+	Server server.Config `group:"HTTP Options" namespace:"http" env-namespace:"HTTP"`
+	root   *Config
+}
+
+// Execute runs HTTP server.
+func (cmd *ServerCommand) Execute([]string) error {
+	return cmd.root.run("server", func(ctx context.Context, obs *observability.Service) error {
+		return runServer(ctx, cmd.Server, obs)
+	})
+}
+
+// ClientCommand holds config vars of `client` command.
+type ClientCommand struct {
+	Client ClientConfig `group:"Client Options" namespace:"client" env-namespace:"CLIENT"`
+	root   *Config
+}
+
+// Execute sends request to HTTP server.
+func (cmd *ClientCommand) Execute([]string) error {
+	return cmd.root.run("client", func(ctx context.Context, obs *observability.Service) error {
+		return runClient(ctx, cmd.Client, obs)
+	})
+}
+
 const application = "observability-example"
 
 var version = "0.0-dev"
@@ -34,26 +58,26 @@ func main() {
 	config.SetApplicationVersion(application, version)
 
 	var cfg Config
+	cfg.Server.root = &cfg
+	cfg.Client.root = &cfg
+	// Open calls Execute of given command
 	err := config.Open(&cfg)
+	config.Close(err, os.Exit)
+}
 
-	defer func() {
-		config.Close(err, os.Exit)
-	}()
-
-	if err != nil {
-		return
-	}
-
-	if err = slogger.Setup(cfg.Logger, nil); err != nil {
-		return
+// run prepares logger and observability for command mode and calls fn.
+func (cfg *Config) run(mode string, fn func(ctx context.Context, obs *observability.Service) error) error {
+	if err := slogger.Setup(cfg.Logger, nil); err != nil {
+		return err
 	}
 
 	ctx := context.Background()
-	serviceName := application + "-" + cfg.Mode
+	serviceName := application + "-" + mode
 
-	var obs *observability.Service
-	if obs, err = observability.New(ctx, cfg.Observability, serviceName, version); err != nil {
-		return
+	obs, err := observability.New(ctx, cfg.Observability, serviceName, version,
+		observability.WithResourceAttributes(config.ReadBuildInfo().Attributes()))
+	if err != nil {
+		return err
 	}
 
 	defer func() {
@@ -62,12 +86,5 @@ func main() {
 		}
 	}()
 
-	switch cfg.Mode {
-	case "server":
-		err = runServer(ctx, cfg, obs)
-	case "client":
-		err = runClient(ctx, cfg.Client, obs)
-	default:
-		err = fmt.Errorf("unsupported mode %q", cfg.Mode)
-	}
+	return fn(ctx, obs)
 }
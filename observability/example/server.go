@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/LeKovr/go-kit/config"
 	"github.com/LeKovr/go-kit/observability"
 	"github.com/LeKovr/go-kit/server"
 	"go.opentelemetry.io/otel/attribute"
@@ -19,7 +20,7 @@ type DemoHandler struct {
 	requests metric.Int64Counter
 }
 
-func runServer(ctx context.Context, cfg Config, obs *observability.Service) error {
+func runServer(ctx context.Context, cfg server.Config, obs *observability.Service) error {
 	const instrumentation = application + "/server"
 
 	demoHandler, err := NewDemoHandler(
@@ -30,7 +31,7 @@ func runServer(ctx context.Context, cfg Config, obs *observability.Service) erro
 		return err
 	}
 
-	srv := server.New(cfg.Server)
+	srv := server.New(cfg).WithBuildInfo(config.ReadBuildInfo())
 	srv.Use(obs.HTTPMiddleware())
 	srv.ServeMux().HandleFunc("/demo", demoHandler.Handle)
 
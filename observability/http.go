@@ -6,7 +6,7 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
-var defaultIgnoredPaths = []string{"/health", "/ready", "/metrics"}
+var defaultIgnoredPaths = []string{"/health", "/ready", "/live", "/metrics"}
 
 type httpConfig struct {
 	ignoredPaths      map[string]struct{}
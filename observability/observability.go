@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/url"
+	"slices"
 	"time"
 
 	otelruntime "go.opentelemetry.io/contrib/instrumentation/runtime"
@@ -25,11 +27,11 @@ import (
 
 // Config holds OpenTelemetry bootstrap options.
 type Config struct {
-	OTLPEndpoint string `default:"http://localhost:4317" long:"exporter_otlp_endpoint" description:"OTLP gRPC endpoint URL" env:"EXPORTER_OTLP_ENDPOINT"`
+	OTLPEndpoint string `default:"http://localhost:4317" long:"exporter_otlp_endpoint" description:"OTLP gRPC endpoint URL" env:"EXPORTER_OTLP_ENDPOINT" validate:"url"`
 	InstanceID   string `long:"service_instance_id" description:"Unique service instance id, e.g. pod name, pod UID, hostname, or container id" env:"SERVICE_INSTANCE_ID"`
 
-	MetricInterval  time.Duration `default:"10s" description:"OTLP metrics export interval" long:"metric_interval" env:"METRIC_INTERVAL"`
-	ShutdownTimeout time.Duration `default:"5s" description:"OpenTelemetry shutdown timeout" long:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	MetricInterval  time.Duration `default:"10s" description:"OTLP metrics export interval" long:"metric_interval" env:"METRIC_INTERVAL" validate:"min=1ms"`
+	ShutdownTimeout time.Duration `default:"5s" description:"OpenTelemetry shutdown timeout" long:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" validate:"min=0s"`
 
 	EnableTraces           bool `description:"Enable traces export" long:"enable_traces" env:"ENABLE_TRACES"`
 	EnableMetrics          bool `description:"Enable metrics export" long:"enable_metrics" env:"ENABLE_METRICS"`
@@ -42,6 +44,7 @@ type Service struct {
 
 	serviceName    string
 	serviceVersion string
+	resourceAttrs  []attribute.KeyValue
 
 	tracerProvider trace.TracerProvider
 	meterProvider  otelmetric.MeterProvider
@@ -49,8 +52,22 @@ type Service struct {
 	shutdowns []func(context.Context) error
 }
 
+// Option changes Service setup.
+type Option func(*Service)
+
+// WithResourceAttributes adds string attributes to the OpenTelemetry resource,
+// e.g. build info from config.ReadBuildInfo().Attributes().
+func WithResourceAttributes(attrs map[string]string) Option {
+	return func(svc *Service) {
+		keys := slices.Sorted(maps.Keys(attrs))
+		for _, key := range keys {
+			svc.resourceAttrs = append(svc.resourceAttrs, attribute.String(key, attrs[key]))
+		}
+	}
+}
+
 // New creates OpenTelemetry providers for traces and metrics.
-func New(ctx context.Context, cfg Config, serviceName, serviceVersion string) (*Service, error) {
+func New(ctx context.Context, cfg Config, serviceName, serviceVersion string, opts ...Option) (*Service, error) {
 	svc := &Service{
 		serviceName:    serviceName,
 		serviceVersion: serviceVersion,
@@ -58,12 +75,15 @@ func New(ctx context.Context, cfg Config, serviceName, serviceVersion string) (*
 		tracerProvider: nooptrace.NewTracerProvider(),
 		meterProvider:  noopmetric.NewMeterProvider(),
 	}
+	for _, opt := range opts {
+		opt(svc)
+	}
 
 	if !cfg.EnableTraces && !cfg.EnableMetrics {
 		return svc, nil
 	}
 
-	r, err := newResource(cfg, serviceName, serviceVersion)
+	r, err := newResource(cfg, serviceName, serviceVersion, svc.resourceAttrs...)
 	if err != nil {
 		return nil, err
 	}
@@ -209,11 +229,12 @@ func (svc Service) propagator() propagation.TextMapPropagator {
 	)
 }
 
-func newResource(cfg Config, serviceName, serviceVersion string) (*resource.Resource, error) {
+func newResource(cfg Config, serviceName, serviceVersion string, extra ...attribute.KeyValue) (*resource.Resource, error) {
 	attrs := []attribute.KeyValue{
 		semconv.ServiceName(serviceName),
 		semconv.ServiceVersion(serviceVersion),
 	}
+	attrs = append(attrs, extra...)
 
 	if cfg.InstanceID != "" {
 		attrs = append(attrs, semconv.ServiceInstanceID(cfg.InstanceID))
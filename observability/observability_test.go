@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	otelmetric "go.opentelemetry.io/otel/metric"
 	noopmetric "go.opentelemetry.io/otel/metric/noop"
 	"go.opentelemetry.io/otel/propagation"
@@ -127,6 +128,29 @@ func TestNewWithTracesAndMetricsDisabledSkipsExporterSetup(t *testing.T) {
 	}
 }
 
+func TestNewResourceWithAttributes(t *testing.T) {
+	svc, err := New(context.Background(), Config{}, "test-service", "test-version",
+		WithResourceAttributes(map[string]string{"vcs.revision": "abc123", "go.version": "go1.25.0"}))
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+
+	r, err := newResource(svc.config, "test-service", "test-version", svc.resourceAttrs...)
+	if err != nil {
+		t.Fatalf("new resource: %v", err)
+	}
+	for key, want := range map[string]string{
+		"service.name": "test-service",
+		"vcs.revision": "abc123",
+		"go.version":   "go1.25.0",
+	} {
+		got, ok := r.Set().Value(attribute.Key(key))
+		if !ok || got.AsString() != want {
+			t.Fatalf("resource attribute %s=%q, want %q", key, got.AsString(), want)
+		}
+	}
+}
+
 func TestServiceHTTPMiddlewareCreatesSpan(t *testing.T) {
 	exporter := tracetest.NewInMemoryExporter()
 	provider := trace.NewTracerProvider(trace.WithSyncer(exporter))
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMEConfig holds options of automatic certificates (Let's Encrypt etc).
+type ACMEConfig struct {
+	Domains      []string `long:"domain" description:"Domain for ACME certificate, enables ACME mode, may be repeated" env:"DOMAINS" env-delim:","`
+	Email        string   `long:"email" description:"Contact email for ACME account" env:"EMAIL"`
+	CacheDir     string   `long:"cache" default:"acme-cache" description:"Directory for certificates and account key" env:"CACHE"`
+	DirectoryURL string   `long:"directory" default:"https://acme-v02.api.letsencrypt.org/directory" description:"ACME directory URL" env:"DIRECTORY" validate:"url"`
+	// CARoot - сертификаты CA сервера ACME, если он использует собственный CA (например, Pebble).
+	CARoot     string `long:"ca_root" description:"CA bundle for ACME server connection" env:"CA_ROOT" validate:"file-exists"`
+	HTTPListen string `long:"http_listen" default:":80" description:"Addr and port for HTTP-01 challenge listener, '-' means disable" env:"HTTP_LISTEN"`
+}
+
+// ACMEListenDisabled holds http_listen value for HTTP-01 listener disabling.
+// TLS-ALPN-01 challenge is answered by main listener anyway.
+const ACMEListenDisabled = "-"
+
+// ErrACMEWithCert returned when both ACME domains and certificate files are set.
+var ErrACMEWithCert = errors.New("ACME mode can not be used with certificate files")
+
+// ErrNoACMECARoot returned when ACME CA root file does not contain certificates.
+var ErrNoACMECARoot = errors.New("no ACME CA certificates found")
+
+// Enabled returns true if ACME mode is enabled.
+func (cfg ACMEConfig) Enabled() bool {
+	return len(cfg.Domains) > 0
+}
+
+// Manager returns certificate manager for configured domains.
+func (cfg ACMEConfig) Manager() (*autocert.Manager, error) {
+	client := &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	if cfg.CARoot != "" {
+		data, err := os.ReadFile(cfg.CARoot)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("ACME CA root %s: %w", cfg.CARoot, ErrNoACMECARoot)
+		}
+		client.HTTPClient = &http.Client{Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		}}
+	}
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Email:      cfg.Email,
+		Client:     client,
+	}, nil
+}
+
+// acmeTLSConfig returns server tls.Config with certificates from ACME manager.
+func acmeTLSConfig(cfg TLSConfig, manager *autocert.Manager) (*tls.Config, error) {
+	rv, err := cfg.ServerConfig()
+	if err != nil {
+		return nil, err
+	}
+	rv.GetCertificate = manager.GetCertificate
+	// TLS-ALPN-01 challenge
+	rv.NextProtos = []string{"h2", "http/1.1", acme.ALPNProto}
+	return rv, nil
+}
+
+// serveACMEChallenge answers HTTP-01 challenges and redirects other requests to HTTPS.
+func serveACMEChallenge(ctx context.Context, cfg Config, manager *autocert.Manager) error {
+	slog.Debug("Start ACME HTTP-01 listener", "addr", cfg.ACME.HTTPListen)
+	listener, err := net.Listen("tcp", cfg.ACME.HTTPListen)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:           manager.HTTPHandler(nil),
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		timedCtx, cancel := context.WithTimeout(context.Background(), cfg.GracePeriod)
+		defer cancel()
+		if err := server.Shutdown(timedCtx); err != nil {
+			slog.Error("ACME HTTP-01 listener shutdown", "err", err)
+		}
+	}()
+	return server.Serve(listener)
+}
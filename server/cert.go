@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// CertExpiryWarning is a period before certificate expiration when warning is logged on load.
+var CertExpiryWarning = 7 * 24 * time.Hour
+
+// fileStamp holds file attributes used for change detection.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// CertLoader holds TLS certificate and reloads it when files are changed.
+// Use GetCertificate as tls.Config.GetCertificate.
+type CertLoader struct {
+	certFile string
+	keyFile  string
+
+	mu     sync.RWMutex
+	cert   *tls.Certificate
+	stamps [2]fileStamp
+}
+
+// newCertLoader returns loader without loaded certificate.
+func newCertLoader(certFile, keyFile string) *CertLoader {
+	return &CertLoader{certFile: certFile, keyFile: keyFile}
+}
+
+// NewCertLoader returns loader with certificate loaded from given files.
+func NewCertLoader(certFile, keyFile string) (*CertLoader, error) {
+	loader := newCertLoader(certFile, keyFile)
+	if err := loader.Reload(); err != nil {
+		return nil, err
+	}
+	return loader, nil
+}
+
+// GetCertificate returns current certificate.
+func (cl *CertLoader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+	if cl.cert == nil {
+		return nil, fmt.Errorf("certificate %s is not loaded", cl.certFile)
+	}
+	return cl.cert, nil
+}
+
+// Reload loads certificate from files.
+// On error current certificate is kept.
+func (cl *CertLoader) Reload() error {
+	stamps, err := cl.fileStamps()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(cl.certFile, cl.keyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate %s: %w", cl.certFile, err)
+	}
+	cl.mu.Lock()
+	cl.cert = &cert
+	cl.stamps = stamps
+	cl.mu.Unlock()
+	if leaf := cert.Leaf; leaf != nil {
+		slog.Info("TLS certificate loaded", "cert", cl.certFile, "subject", leaf.Subject.String(), "expires", leaf.NotAfter)
+		if time.Until(leaf.NotAfter) < CertExpiryWarning {
+			slog.Warn("TLS certificate expires soon", "cert", cl.certFile, "expires", leaf.NotAfter)
+		}
+	}
+	return nil
+}
+
+// Watch reloads certificate on SIGHUP and when files are changed (checked every interval).
+// Zero interval disables file checks. Reload errors are logged, previous certificate is kept.
+func (cl *CertLoader) Watch(ctx context.Context, interval time.Duration) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			slog.Info("TLS certificate reload requested", "cert", cl.certFile)
+			cl.reload()
+		case <-tick:
+			if cl.isChanged() {
+				slog.Info("TLS certificate files changed", "cert", cl.certFile)
+				cl.reload()
+			}
+		}
+	}
+}
+
+// reload calls Reload and logs error.
+func (cl *CertLoader) reload() {
+	if err := cl.Reload(); err != nil {
+		slog.Error("TLS certificate reload, keep previous", "err", err)
+	}
+}
+
+// isChanged returns true if certificate files differ from loaded ones.
+func (cl *CertLoader) isChanged() bool {
+	stamps, err := cl.fileStamps()
+	if err != nil {
+		// file may be in the middle of replacement
+		slog.Debug("TLS certificate stat", "err", err)
+		return false
+	}
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+	return stamps != cl.stamps
+}
+
+// fileStamps returns attributes of certificate and key files.
+func (cl *CertLoader) fileStamps() ([2]fileStamp, error) {
+	var rv [2]fileStamp
+	for i, name := range []string{cl.certFile, cl.keyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return rv, err
+		}
+		rv[i] = fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+	}
+	return rv, nil
+}
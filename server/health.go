@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Health check URLs registered by WithHealth.
+const (
+	HealthPath = "/health" // checks state
+	ReadyPath  = "/ready"  // checks state, fails on shutdown
+	LivePath   = "/live"   // process is running
+)
+
+// Health check statuses.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// HealthConfig holds health check options.
+type HealthConfig struct {
+	Timeout       time.Duration `long:"timeout" default:"5s" description:"Default health check timeout" validate:"min=0s"`
+	ShutdownDelay time.Duration `long:"shutdown_delay" default:"0s" description:"Delay of HTTP server stop while /ready fails" validate:"min=0s"`
+}
+
+// Check is a component health check.
+type Check func(ctx context.Context) error
+
+// CheckResult holds result of health check.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// HealthResponse holds health check response.
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type namedCheck struct {
+	name    string
+	timeout time.Duration
+	check   Check
+}
+
+// health holds registered checks and shutdown state.
+type health struct {
+	mu       sync.RWMutex
+	checks   []namedCheck
+	stopping atomic.Bool
+}
+
+// WithHealth registers /health, /ready and /live handlers.
+// /ready fails after shutdown start, so load balancers stop sending traffic
+// (see HealthConfig.ShutdownDelay).
+func (srv *Service) WithHealth() *Service {
+	h := srv.healthState()
+	srv.mux.HandleFunc(LivePath, func(w http.ResponseWriter, _ *http.Request) {
+		writeHealth(w, HealthResponse{Status: StatusOK})
+	})
+	srv.mux.HandleFunc(HealthPath, func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, h.run(r.Context(), srv.config.Health.Timeout))
+	})
+	srv.mux.HandleFunc(ReadyPath, func(w http.ResponseWriter, r *http.Request) {
+		if h.stopping.Load() {
+			writeHealth(w, HealthResponse{Status: StatusFail})
+			return
+		}
+		writeHealth(w, h.run(r.Context(), srv.config.Health.Timeout))
+	})
+	return srv
+}
+
+// AddCheck registers named health check.
+// Zero timeout means HealthConfig.Timeout.
+func (srv *Service) AddCheck(name string, timeout time.Duration, check Check) *Service {
+	h := srv.healthState()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedCheck{name: name, timeout: timeout, check: check})
+	return srv
+}
+
+// healthState returns health state, allocated on first call.
+func (srv *Service) healthState() *health {
+	if srv.health == nil {
+		srv.health = &health{}
+	}
+	return srv.health
+}
+
+// markStopping switches readiness to failing.
+func (srv *Service) markStopping() {
+	if srv.health != nil {
+		srv.health.stopping.Store(true)
+	}
+}
+
+// run calls all checks concurrently.
+func (h *health) run(ctx context.Context, timeout time.Duration) HealthResponse {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+	rv := HealthResponse{Status: StatusOK}
+	if len(checks) == 0 {
+		return rv
+	}
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Go(func() {
+			results[i] = c.run(ctx, timeout)
+		})
+	}
+	wg.Wait()
+	rv.Checks = make(map[string]CheckResult, len(checks))
+	for i, c := range checks {
+		if results[i].Status != StatusOK {
+			rv.Status = StatusFail
+		}
+		rv.Checks[c.name] = results[i]
+	}
+	return rv
+}
+
+// run calls check with timeout.
+func (c namedCheck) run(ctx context.Context, timeout time.Duration) CheckResult {
+	if c.timeout != 0 {
+		timeout = c.timeout
+	}
+	if timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		// check ignores context
+		err = ctx.Err()
+	}
+	rv := CheckResult{Status: StatusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		rv.Status = StatusFail
+		rv.Error = err.Error()
+	}
+	return rv
+}
+
+// writeHealth writes response with status 503 on failure.
+func writeHealth(w http.ResponseWriter, resp HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if resp.Status != StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("Health response", "err", err)
+	}
+}
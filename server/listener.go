@@ -0,0 +1,130 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// UnixPrefix is a prefix of unix domain socket address (unix:/run/app.sock).
+const UnixPrefix = "unix:"
+
+// ListenerConfig holds options of additional listener.
+// Zero timeouts are taken from main Config.
+type ListenerConfig struct {
+	Listen            string        `long:"listen" description:"Addr and port (or unix:/path/to.sock) which listener uses, empty means disable"`
+	MaxHeaderBytes    int           `long:"maxheader" description:"MaxHeaderBytes" validate:"min=0"`
+	ReadTimeout       time.Duration `long:"rto" description:"HTTP read timeout" validate:"min=0s"`
+	WriteTimeout      time.Duration `long:"wto" description:"HTTP write timeout" validate:"min=0s"`
+	ReadHeaderTimeout time.Duration `long:"rhto" description:"HTTP read header timeout" validate:"min=0s"`
+	IdleTimeout       time.Duration `long:"ito" description:"HTTP idle timeout" validate:"min=0s"`
+}
+
+// namedListener holds additional listener attributes.
+type namedListener struct {
+	name     string
+	config   ListenerConfig
+	handler  http.Handler
+	listener net.Listener
+}
+
+// AddListener registers additional plain HTTP listener with its own handler
+// (e.g. admin mux or RedirectHTTPS) and handlers chain, applied like Use.
+// Listener with empty cfg.Listen is skipped.
+// Listeners are started by Run and stopped with main HTTP server.
+func (srv *Service) AddListener(name string, cfg ListenerConfig, handler http.Handler, handlers ...Handler) *Service {
+	if cfg.Listen == "" {
+		slog.Debug("Listener disabled", "name", name)
+		return srv
+	}
+	for _, h := range handlers {
+		handler = h(handler)
+	}
+	srv.listeners = append(srv.listeners, &namedListener{name: name, config: cfg, handler: handler})
+	return srv
+}
+
+// RedirectHTTPS returns handler which redirects requests to HTTPS on given port
+// (empty or "443" means default port).
+func RedirectHTTPS(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// listen opens tcp listener or unix socket (address with UnixPrefix).
+// Stale socket file is removed.
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, UnixPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// listenerWorkers opens additional listeners and returns their workers.
+func (srv *Service) listenerWorkers(ctx context.Context) ([]Worker, error) {
+	cfg := srv.config
+	var workers []Worker
+	for _, nl := range srv.listeners {
+		slog.Debug("Start Listener", "name", nl.name, "addr", nl.config.Listen)
+		listener, err := listen(nl.config.Listen)
+		if err != nil {
+			srv.closeListeners()
+			return nil, fmt.Errorf("listener %s: %w", nl.name, err)
+		}
+		nl.listener = listener
+		lc := nl.config
+		server := &http.Server{
+			Handler:           nl.handler,
+			MaxHeaderBytes:    cmp.Or(lc.MaxHeaderBytes, cfg.MaxHeaderBytes),
+			ReadTimeout:       cmp.Or(lc.ReadTimeout, cfg.ReadTimeout),
+			WriteTimeout:      cmp.Or(lc.WriteTimeout, cfg.WriteTimeout),
+			ReadHeaderTimeout: cmp.Or(lc.ReadHeaderTimeout, cfg.ReadHeaderTimeout),
+			IdleTimeout:       cmp.Or(lc.IdleTimeout, cfg.IdleTimeout),
+			BaseContext: func(_ net.Listener) context.Context {
+				return ctx
+			},
+		}
+		workers = append(workers,
+			func(_ context.Context) error {
+				slog.Debug("Start HTTP service", "name", nl.name)
+				return server.Serve(listener)
+			},
+			func(ctx context.Context) error {
+				<-ctx.Done()
+				timedCtx, cancel := context.WithTimeout(context.Background(), cfg.GracePeriod)
+				defer cancel()
+				return server.Shutdown(timedCtx)
+			},
+		)
+	}
+	return workers, nil
+}
+
+// closeListeners closes opened additional listeners.
+func (srv *Service) closeListeners() {
+	for _, nl := range srv.listeners {
+		if nl.listener != nil {
+			nl.listener.Close()
+		}
+	}
+}
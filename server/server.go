@@ -2,7 +2,7 @@ package server
 
 import (
 	"context"
-	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -22,9 +23,16 @@ import (
 
 // TLSConfig holds TLS config options.
 type TLSConfig struct {
-	CertFile           string `long:"cert" description:"CertFile for serving HTTPS instead HTTP" env:"CERT"`
-	KeyFile            string `long:"key"  description:"KeyFile for serving HTTPS instead HTTP" env:"KEY"`
-	NoCheckCertificate bool   `long:"no-check" description:"disable tls certificate validation"`
+	CertFile           string `long:"cert" description:"CertFile for serving HTTPS instead HTTP" env:"CERT" validate:"file-exists"`
+	KeyFile            string `long:"key"  description:"KeyFile for serving HTTPS instead HTTP" env:"KEY" validate:"file-exists"`
+	NoCheckCertificate bool   `long:"no-check" description:"disable tls certificate validation" deprecated:"not used by server"`
+	// ClientCA - сертификаты CA для проверки сертификатов клиентов (mTLS).
+	ClientCA     string   `long:"client_ca" description:"CA bundle for client certificates verification" env:"CLIENT_CA" validate:"file-exists"`
+	ClientAuth   string   `long:"client_auth" default:"none" description:"Client certificate authentication mode" env:"CLIENT_AUTH" choice:"none" choice:"request" choice:"require" choice:"verify"`
+	MinVersion   string   `long:"min_version" default:"1.2" description:"Minimal TLS version" choice:"1.0" choice:"1.1" choice:"1.2" choice:"1.3"`
+	CipherSuites []string `long:"cipher" description:"Allowed cipher suite (TLS 1.0-1.2), may be repeated"`
+	// Reload - интервал проверки изменения файлов сертификата (кроме того, сертификат перечитывается по SIGHUP).
+	Reload time.Duration `long:"reload" default:"1m" description:"Certificate files check interval, '0' means reload on SIGHUP only" validate:"min=0s"`
 }
 
 // VersionResponseConfig holds settings for HTTP version response.
@@ -32,18 +40,20 @@ type VersionResponseConfig struct {
 	Prefix string `long:"prefix" default:"/js/version.js" description:"URL for version response"`
 	Format string `long:"format" default:"document.addEventListener('DOMContentLoaded', () => { appVersion.innerText = '%s'; });\n" description:"Format string for version response"`
 	CType  string `long:"ctype"  default:"text/javascript" description:"js code Content-Type header"`
+	// InfoPrefix - адрес ответа WithBuildInfo.
+	InfoPrefix string `long:"info_prefix" default:"/version.json" description:"URL for build info response"`
 }
 
 // Config holds all config vars.
 type Config struct {
-	Listen string `long:"listen" default:":8080" description:"Addr and port which server listens at" env:"LISTEN"`
+	Listen string `long:"listen" default:":8080" description:"Addr and port (or unix:/path/to.sock) which server listens at" env:"LISTEN" validate:"hostport"`
 
-	MaxHeaderBytes    int           `long:"maxheader" description:"MaxHeaderBytes"`
-	ReadTimeout       time.Duration `long:"rto" default:"10s" description:"HTTP read timeout"`
-	WriteTimeout      time.Duration `long:"wto" default:"60s" description:"HTTP write timeout, '0' means disable"`
-	ReadHeaderTimeout time.Duration `long:"rhto" default:"10s" description:"HTTP read header timeout"`
-	IdleTimeout       time.Duration `long:"ito" default:"10s" description:"HTTP idle timeout"`
-	GracePeriod       time.Duration `long:"grace" default:"10s" description:"Stop grace period"`
+	MaxHeaderBytes    int           `long:"maxheader" description:"MaxHeaderBytes" validate:"min=0"`
+	ReadTimeout       time.Duration `long:"rto" default:"10s" description:"HTTP read timeout" validate:"min=0s"`
+	WriteTimeout      time.Duration `long:"wto" default:"60s" description:"HTTP write timeout, '0' means disable" validate:"min=0s"`
+	ReadHeaderTimeout time.Duration `long:"rhto" default:"10s" description:"HTTP read header timeout" validate:"min=0s"`
+	IdleTimeout       time.Duration `long:"ito" default:"10s" description:"HTTP idle timeout" validate:"min=0s"`
+	GracePeriod       time.Duration `long:"grace" default:"10s" description:"Stop grace period" validate:"min=0s"`
 
 	IPHeader   string `long:"ip_header" env:"IP_HEADER" default:"X-Real-IP" description:"HTTP Request Header for remote IP"`
 	UserHeader string `long:"user_header" env:"USER_HEADER" default:"X-Username" description:"HTTP Request Header for username"`
@@ -52,6 +62,8 @@ type Config struct {
 
 	TLS     TLSConfig             `group:"HTTPS Options"            namespace:"tls"  env-namespace:"TLS"`
 	Version VersionResponseConfig `group:"Version response Options" namespace:"vr"`
+	Health  HealthConfig          `group:"Health check Options"     namespace:"health"`
+	ACME    ACMEConfig            `group:"ACME Options"             namespace:"acme" env-namespace:"ACME"`
 }
 
 // Handler is a http midleware handler.
@@ -70,6 +82,8 @@ type Service struct {
 	workers         []Worker
 	onShutdown      *Worker
 	accessLogWriter io.Writer
+	health          *health
+	listeners       []*namedListener
 }
 
 // AccessLogDisabled holds access_log value for access logging disabling.
@@ -107,6 +121,22 @@ func (srv *Service) WithVersion(version string) *Service {
 	return srv
 }
 
+// WithBuildInfo sets handler returning build info (e.g. config.ReadBuildInfo()) as JSON.
+func (srv *Service) WithBuildInfo(info any) *Service {
+	data, err := json.Marshal(info)
+	if err != nil {
+		slog.Error("Build info marshal", "err", err)
+		return srv
+	}
+	srv.mux.HandleFunc(srv.config.Version.InfoPrefix, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(data); err != nil {
+			slog.Error("Build info response", "err", err)
+		}
+	})
+	return srv
+}
+
 // Use adds handler for muxer.
 func (srv *Service) Use(handler Handler) *Service {
 	srv.handlers = append(srv.handlers, handler)
@@ -164,17 +194,53 @@ func (srv *Service) WithHTTPWorkers() *Service {
 	if cfg.WriteTimeout != 0 {
 		server.WriteTimeout = cfg.WriteTimeout
 	}
-	if cfg.TLS.NoCheckCertificate {
-		server.TLSConfig = &tls.Config{InsecureSkipVerify: true}
-	}
 
-	workers := make([]Worker, 2)
-	if srv.config.TLS.CertFile != "" {
+	workers := make([]Worker, 2, 3)
+	switch {
+	case cfg.ACME.Enabled():
+		manager := sync.OnceValues(cfg.ACME.Manager)
 		workers[0] = func(_ context.Context) error {
+			if cfg.TLS.CertFile != "" {
+				return ErrACMEWithCert
+			}
+			m, err := manager()
+			if err != nil {
+				return err
+			}
+			if server.TLSConfig, err = acmeTLSConfig(cfg.TLS, m); err != nil {
+				return err
+			}
+			slog.Debug("Start HTTPS service", "acme", cfg.ACME.Domains)
+			return server.ServeTLS(srv.listener, "", "")
+		}
+		if cfg.ACME.HTTPListen != ACMEListenDisabled {
+			workers = append(workers, func(ctx context.Context) error {
+				m, err := manager()
+				if err != nil {
+					return err
+				}
+				return serveACMEChallenge(ctx, cfg, m)
+			})
+		}
+	case cfg.TLS.CertFile != "":
+		loader := newCertLoader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		workers[0] = func(_ context.Context) error {
+			tlsConfig, err := cfg.TLS.ServerConfig()
+			if err != nil {
+				return err
+			}
+			if err = loader.Reload(); err != nil {
+				return err
+			}
+			tlsConfig.GetCertificate = loader.GetCertificate
+			server.TLSConfig = tlsConfig
 			slog.Debug("Start HTTPS service")
-			return server.ServeTLS(srv.listener, srv.config.TLS.CertFile, srv.config.TLS.KeyFile)
+			return server.ServeTLS(srv.listener, "", "")
 		}
-	} else {
+		workers = append(workers, func(ctx context.Context) error {
+			return loader.Watch(ctx, cfg.TLS.Reload)
+		})
+	default:
 		workers[0] = func(_ context.Context) error {
 			slog.Debug("Start HTTP service")
 			return server.Serve(srv.listener)
@@ -182,9 +248,18 @@ func (srv *Service) WithHTTPWorkers() *Service {
 	}
 	workers[1] = func(ctx context.Context) error {
 		<-ctx.Done()
-		timedCtx, cancel := context.WithTimeout(ctx, cfg.GracePeriod)
+		srv.markStopping()
+		if cfg.Health.ShutdownDelay > 0 {
+			// /ready fails, give load balancers time to drain traffic
+			time.Sleep(cfg.Health.ShutdownDelay)
+		}
+		// ctx is already cancelled, in-flight requests get GracePeriod
+		timedCtx, cancel := context.WithTimeout(context.Background(), cfg.GracePeriod)
 		defer cancel()
-		return server.Shutdown(timedCtx)
+		err := server.Shutdown(timedCtx)
+		// requests are finished, access log is not needed anymore
+		srv.closeAccessLog()
+		return err
 	}
 	srv.server = server
 	srv.WithWorkers(workers...)
@@ -196,7 +271,7 @@ func (srv *Service) Run(ctx context.Context, workers ...Worker) error {
 	cfg := srv.config
 	if srv.listener == nil {
 		slog.Debug("Start Listener", "addr", cfg.Listen)
-		listener, err := net.Listen("tcp", cfg.Listen)
+		listener, err := listen(cfg.Listen)
 		if err != nil {
 			return err
 		}
@@ -207,6 +282,9 @@ func (srv *Service) Run(ctx context.Context, workers ...Worker) error {
 	}
 	server := srv.server
 	server.Handler = srv.ServeMuxWithHandlers() // Use aclual handlers list.
+	if cfg.TLS.CertFile != "" || cfg.ACME.Enabled() {
+		server.Handler = clientIdentityHandler(server.Handler)
+	}
 	server.BaseContext = func(_ net.Listener) context.Context {
 		return ctx
 	}
@@ -214,14 +292,20 @@ func (srv *Service) Run(ctx context.Context, workers ...Worker) error {
 		if cfg.AccessLog != "" {
 			writer, err := os.OpenFile(cfg.AccessLog, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
 			if err != nil {
+				srv.listener.Close()
 				return err
 			}
 			srv.accessLogWriter = writer
 		}
 		server.Handler = srv.accessLogHandler(server.Handler)
 	}
-
-	return srv.WithWorkers(workers...).run(ctx)
+	listenerWorkers, err := srv.listenerWorkers(ctx)
+	if err != nil {
+		srv.listener.Close()
+		srv.closeAccessLog()
+		return err
+	}
+	return srv.WithWorkers(listenerWorkers...).WithWorkers(workers...).run(ctx)
 }
 
 // RunWorkers runs workers without HTTP service.
@@ -254,17 +338,10 @@ func (srv *Service) run(ctxParent context.Context) error {
 func (srv *Service) shutdownWorker(ctx context.Context) error {
 	<-ctx.Done()
 	slog.Debug("Shutdown")
+	srv.markStopping()
 	timedCtx, cancel := context.WithTimeout(context.Background(), srv.config.GracePeriod)
 	defer cancel()
 	var err error
-
-	if srv.accessLogWriter != nil {
-		if f, ok := srv.accessLogWriter.(*os.File); ok {
-			f.Close()
-			slog.Info("Log closed")
-		}
-	}
-
 	if srv.onShutdown != nil {
 		w := *srv.onShutdown
 		err = w(timedCtx)
@@ -272,6 +349,14 @@ func (srv *Service) shutdownWorker(ctx context.Context) error {
 	return err
 }
 
+// closeAccessLog closes access log file if it was opened.
+func (srv *Service) closeAccessLog() {
+	if f, ok := srv.accessLogWriter.(*os.File); ok {
+		f.Close()
+		slog.Info("Log closed")
+	}
+}
+
 // WithAccessLog calculates estimate and prints HTTP request log.
 func (srv Service) accessLogHandler(handler http.Handler) http.Handler {
 	var writer io.Writer = os.Stdout
@@ -285,7 +370,10 @@ func (srv Service) accessLogHandler(handler http.Handler) http.Handler {
 		if ip == "" {
 			ip, _, _ = net.SplitHostPort(r.RemoteAddr)
 		}
-		user := r.Header.Get(cfg.UserHeader)
+		user := verifiedSubject(r)
+		if user == "" {
+			user = r.Header.Get(cfg.UserHeader)
+		}
 		if user == "" {
 			user = "-"
 		}
@@ -2,13 +2,27 @@ package server
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"io"
+	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -81,6 +95,31 @@ func TestWithVersion(t *testing.T) {
 	}
 }
 
+func TestWithBuildInfo(t *testing.T) {
+	srv := New(Config{Listen: ":0", Version: VersionResponseConfig{InfoPrefix: "/version.json"}})
+	srv.WithBuildInfo(struct {
+		Version string `json:"version"`
+	}{"1.2.3"})
+	ts := httptest.NewServer(srv.ServeMux())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/version.json")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	if string(body) != `{"version":"1.2.3"}` {
+		t.Fatalf("unexpected body: %s", string(body))
+	}
+}
+
 func TestUseMiddleware(t *testing.T) {
 	srv := New(Config{Listen: ":0"})
 	// middleware that sets header
@@ -243,6 +282,798 @@ func TestRun(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 }
 
+func getHealth(t *testing.T, url string) (int, HealthResponse) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	var rv HealthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rv); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp.StatusCode, rv
+}
+
+func TestWithHealth(t *testing.T) {
+	srv := New(Config{Listen: ":0", Health: HealthConfig{Timeout: time.Second}}).WithHealth()
+	srv.AddCheck("db", 0, func(context.Context) error { return nil })
+	ts := httptest.NewServer(srv.ServeMux())
+	defer ts.Close()
+
+	for _, path := range []string{LivePath, HealthPath, ReadyPath} {
+		code, resp := getHealth(t, ts.URL+path)
+		if code != http.StatusOK || resp.Status != StatusOK {
+			t.Fatalf("%s: unexpected response: %d %+v", path, code, resp)
+		}
+	}
+
+	srv.AddCheck("cache", 0, func(context.Context) error { return errors.New("no connection") })
+	srv.AddCheck("slow", 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	code, resp := getHealth(t, ts.URL+HealthPath)
+	if code != http.StatusServiceUnavailable || resp.Status != StatusFail {
+		t.Fatalf("unexpected response: %d %+v", code, resp)
+	}
+	if r := resp.Checks["db"]; r.Status != StatusOK {
+		t.Fatalf("unexpected db result: %+v", r)
+	}
+	if r := resp.Checks["cache"]; r.Status != StatusFail || r.Error != "no connection" {
+		t.Fatalf("unexpected cache result: %+v", r)
+	}
+	if r := resp.Checks["slow"]; r.Status != StatusFail || r.Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("unexpected slow result: %+v", r)
+	}
+	if code, _ := getHealth(t, ts.URL+LivePath); code != http.StatusOK {
+		t.Fatalf("live must not run checks: %d", code)
+	}
+}
+
+func TestReadyOnShutdown(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "access.log")
+	srv := New(Config{
+		Listen:      ":0",
+		AccessLog:   logFile,
+		GracePeriod: time.Second,
+		Health:      HealthConfig{ShutdownDelay: 300 * time.Millisecond},
+	}).WithHealth()
+	srv.mux.HandleFunc("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(400 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- srv.WithListener(ln).Run(ctx)
+	}()
+	url := "http://" + ln.Addr().String()
+	time.Sleep(100 * time.Millisecond)
+	if code, _ := getHealth(t, url+ReadyPath); code != http.StatusOK {
+		t.Fatalf("unexpected ready status: %d", code)
+	}
+	slow := make(chan error)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		slow <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	// server is still running during ShutdownDelay
+	if code, _ := getHealth(t, url+ReadyPath); code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected ready status on shutdown: %d", code)
+	}
+	if code, _ := getHealth(t, url+LivePath); code != http.StatusOK {
+		t.Fatalf("unexpected live status on shutdown: %d", code)
+	}
+	if err := <-slow; err != nil {
+		t.Fatalf("in-flight request must be drained: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("read access log: %v", err)
+	}
+	for _, path := range []string{LivePath, "/slow"} {
+		if !strings.Contains(string(data), path) {
+			t.Fatalf("access log misses %s request made on shutdown:\n%s", path, string(data))
+		}
+	}
+}
+
+// issueCert returns PEM encoded certificate signed by parent (self-signed if nil) and its key.
+func issueCert(t *testing.T, tmpl, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+// writeCert writes self-signed certificate with given serial and its key.
+func writeCert(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+	_, _, certPEM, keyPEM := issueCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, nil, nil)
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	// change detection must not depend on mtime resolution
+	mtime := time.Now().Add(time.Duration(serial) * time.Second)
+	for _, name := range []string{certFile, keyFile} {
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+}
+
+func certSerial(t *testing.T, loader *CertLoader) int64 {
+	t.Helper()
+	cert, err := loader.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate: %v", err)
+	}
+	return cert.Leaf.SerialNumber.Int64()
+}
+
+func TestCertLoader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCert(t, certFile, keyFile, 1)
+	loader, err := NewCertLoader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertLoader: %v", err)
+	}
+	if serial := certSerial(t, loader); serial != 1 {
+		t.Fatalf("unexpected serial: %d", serial)
+	}
+
+	// invalid pair keeps previous certificate
+	if err := os.WriteFile(certFile, []byte("broken"), 0o600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+	if err := loader.Reload(); err == nil {
+		t.Fatalf("Reload of invalid certificate must fail")
+	}
+	if serial := certSerial(t, loader); serial != 1 {
+		t.Fatalf("unexpected serial after failed reload: %d", serial)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- loader.Watch(ctx, 10*time.Millisecond)
+	}()
+	writeCert(t, certFile, keyFile, 2)
+	deadline := time.Now().Add(2 * time.Second)
+	for certSerial(t, loader) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("certificate was not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	if _, err := NewCertLoader(filepath.Join(dir, "none.crt"), keyFile); err == nil {
+		t.Fatalf("NewCertLoader must fail for missing file")
+	}
+}
+
+func TestRunTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCert(t, certFile, keyFile, 3)
+	srv := New(Config{Listen: ":0", AccessLog: AccessLogDisabled, TLS: TLSConfig{CertFile: certFile, KeyFile: keyFile}})
+	srv.mux.HandleFunc("/ping", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("pong"))
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- srv.WithListener(ln).Run(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}} //nolint:gosec
+	resp, err := client.Get("https://" + ln.Addr().String() + "/ping")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if serial := resp.TLS.PeerCertificates[0].SerialNumber.Int64(); serial != 3 {
+		t.Fatalf("unexpected serial: %d", serial)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestServerConfig(t *testing.T) {
+	tlsConfig, err := TLSConfig{ClientAuth: ClientAuthRequire, MinVersion: "1.3"}.ServerConfig()
+	if err != nil {
+		t.Fatalf("ServerConfig: %v", err)
+	}
+	if tlsConfig.ClientAuth != tls.RequireAnyClientCert || tlsConfig.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("unexpected config: %v %v", tlsConfig.ClientAuth, tlsConfig.MinVersion)
+	}
+	tlsConfig, err = TLSConfig{CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}}.ServerConfig()
+	if err != nil {
+		t.Fatalf("ServerConfig: %v", err)
+	}
+	if len(tlsConfig.CipherSuites) != 1 || tlsConfig.CipherSuites[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+		t.Fatalf("unexpected cipher suites: %v", tlsConfig.CipherSuites)
+	}
+	if _, err = (TLSConfig{CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}}).ServerConfig(); err == nil {
+		t.Fatalf("insecure cipher suite must be rejected")
+	}
+	if _, err = (TLSConfig{ClientAuth: "always"}).ServerConfig(); err == nil {
+		t.Fatalf("unknown client auth must be rejected")
+	}
+	ca := filepath.Join(t.TempDir(), "ca.crt")
+	if err = os.WriteFile(ca, []byte("none"), 0o600); err != nil {
+		t.Fatalf("write ca: %v", err)
+	}
+	if _, err = (TLSConfig{ClientCA: ca, ClientAuth: ClientAuthVerify}).ServerConfig(); !errors.Is(err, ErrNoClientCA) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = (TLSConfig{ClientAuth: ClientAuthVerify}).ServerConfig(); !errors.Is(err, ErrClientCARequired) {
+		t.Fatalf("verify without client CA must be rejected: %v", err)
+	}
+	for _, auth := range []string{"", ClientAuthNone} {
+		if _, err = (TLSConfig{ClientCA: ca, ClientAuth: auth}).ServerConfig(); !errors.Is(err, ErrClientCAUnused) {
+			t.Fatalf("client CA with client auth %q must be rejected: %v", auth, err)
+		}
+	}
+}
+
+func TestRunMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	caCert, caKey, caPEM, _ := issueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	_, _, srvPEM, srvKeyPEM := issueCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, caCert, caKey)
+	_, _, clientPEM, clientKeyPEM := issueCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client", Organization: []string{"Org"}},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCert, caKey)
+	files := map[string][]byte{"ca.crt": caPEM, "tls.crt": srvPEM, "tls.key": srvKeyPEM}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	logFile := filepath.Join(dir, "access.log")
+	srv := New(Config{Listen: ":0", AccessLog: logFile, UserHeader: "X-Username", TLS: TLSConfig{
+		CertFile:   filepath.Join(dir, "tls.crt"),
+		KeyFile:    filepath.Join(dir, "tls.key"),
+		ClientCA:   filepath.Join(dir, "ca.crt"),
+		ClientAuth: ClientAuthVerify,
+	}})
+	srv.mux.HandleFunc("/whoami", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ClientSubject(r.Context())))
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- srv.WithListener(ln).Run(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	clientCert, err := tls.X509KeyPair(clientPEM, clientKeyPEM)
+	if err != nil {
+		t.Fatalf("client key pair: %v", err)
+	}
+	url := "https://" + ln.Addr().String() + "/whoami"
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{clientCert},
+	}}}
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("X-Username", "header-user")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	if string(body) != "CN=client,O=Org" {
+		t.Fatalf("unexpected subject: %s", string(body))
+	}
+
+	// client without certificate is rejected
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	if resp, err = anonymous.Get(url); err == nil {
+		resp.Body.Close()
+		t.Fatalf("request without client certificate must fail")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if !strings.Contains(string(data), " - CN=client,O=Org [") {
+		t.Fatalf("access log does not contain subject: %s", string(data))
+	}
+}
+
+func TestACMEManager(t *testing.T) {
+	cfg := ACMEConfig{
+		Domains:      []string{"app.example"},
+		CacheDir:     t.TempDir(),
+		DirectoryURL: "https://acme.test/dir",
+	}
+	if !cfg.Enabled() || (ACMEConfig{}).Enabled() {
+		t.Fatalf("unexpected Enabled result")
+	}
+	m, err := cfg.Manager()
+	if err != nil {
+		t.Fatalf("Manager: %v", err)
+	}
+	if m.Client.DirectoryURL != cfg.DirectoryURL {
+		t.Fatalf("unexpected directory: %s", m.Client.DirectoryURL)
+	}
+	if err = m.HostPolicy(context.Background(), "app.example"); err != nil {
+		t.Fatalf("HostPolicy: %v", err)
+	}
+	if err = m.HostPolicy(context.Background(), "other.example"); err == nil {
+		t.Fatalf("HostPolicy must reject unknown domain")
+	}
+	tlsConfig, err := acmeTLSConfig(TLSConfig{}, m)
+	if err != nil {
+		t.Fatalf("acmeTLSConfig: %v", err)
+	}
+	if !slices.Contains(tlsConfig.NextProtos, "acme-tls/1") {
+		t.Fatalf("TLS-ALPN-01 protocol is not enabled: %v", tlsConfig.NextProtos)
+	}
+
+	cfg.CARoot = filepath.Join(t.TempDir(), "ca.pem")
+	if err = os.WriteFile(cfg.CARoot, []byte("none"), 0o600); err != nil {
+		t.Fatalf("write ca: %v", err)
+	}
+	if _, err = cfg.Manager(); !errors.Is(err, ErrNoACMECARoot) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRunACMEWithCert(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := New(Config{
+		AccessLog: AccessLogDisabled,
+		TLS:       TLSConfig{CertFile: "tls.crt"},
+		ACME:      ACMEConfig{Domains: []string{"app.example"}, HTTPListen: ACMEListenDisabled},
+	})
+	if err = srv.WithListener(ln).Run(context.Background()); !errors.Is(err, ErrACMEWithCert) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// fakeACME is a minimal RFC 8555 server which validates HTTP-01 challenges
+// on httpAddr and issues certificates signed by its own CA.
+type fakeACME struct {
+	t        *testing.T
+	httpAddr string
+	caCert   *x509.Certificate
+	caKey    *ecdsa.PrivateKey
+	srv      *httptest.Server
+
+	mu      sync.Mutex
+	status  map[string]string // authz and order statuses
+	certPEM []byte
+}
+
+func newFakeACME(t *testing.T, httpAddr string) *fakeACME {
+	t.Helper()
+	caCert, caKey, _, _ := issueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fake ACME CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	fa := &fakeACME{t: t, httpAddr: httpAddr, caCert: caCert, caKey: caKey, status: map[string]string{
+		"authz": "pending",
+		"order": "pending",
+	}}
+	fa.srv = httptest.NewTLSServer(http.HandlerFunc(fa.serveHTTP))
+	t.Cleanup(fa.srv.Close)
+	return fa
+}
+
+// payload returns decoded JWS payload of request.
+func (fa *fakeACME) payload(r *http.Request) []byte {
+	var jws struct {
+		Payload string `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		fa.t.Errorf("decode JWS: %v", err)
+		return nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(jws.Payload)
+	if err != nil {
+		fa.t.Errorf("decode payload: %v", err)
+	}
+	return data
+}
+
+func (fa *fakeACME) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	url := fa.srv.URL
+	w.Header().Set("Replay-Nonce", strconv.FormatInt(time.Now().UnixNano(), 36))
+	reply := func(code int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(v)
+	}
+	fa.mu.Lock()
+	defer fa.mu.Unlock()
+	challenge := map[string]string{"type": "http-01", "url": url + "/chal", "token": "token1", "status": fa.status["authz"]}
+	order := map[string]any{
+		"status":         fa.status["order"],
+		"identifiers":    []map[string]string{{"type": "dns", "value": "app.example"}},
+		"authorizations": []string{url + "/authz"},
+		"finalize":       url + "/finalize",
+	}
+	if fa.status["order"] == "valid" {
+		order["certificate"] = url + "/cert"
+	}
+	switch r.URL.Path {
+	case "/dir":
+		reply(http.StatusOK, map[string]string{
+			"newNonce":   url + "/nonce",
+			"newAccount": url + "/account",
+			"newOrder":   url + "/order",
+			"revokeCert": url + "/revoke",
+			"keyChange":  url + "/key",
+		})
+	case "/nonce":
+		w.WriteHeader(http.StatusOK)
+	case "/account":
+		w.Header().Set("Location", url+"/account/1")
+		reply(http.StatusCreated, map[string]string{"status": "valid"})
+	case "/order":
+		w.Header().Set("Location", url+"/order/1")
+		reply(http.StatusCreated, order)
+	case "/order/1":
+		w.Header().Set("Location", url+"/order/1")
+		reply(http.StatusOK, order)
+	case "/authz":
+		reply(http.StatusOK, map[string]any{
+			"status":     fa.status["authz"],
+			"identifier": map[string]string{"type": "dns", "value": "app.example"},
+			"challenges": []map[string]string{challenge},
+		})
+	case "/chal":
+		// HTTP-01 validation
+		req, _ := http.NewRequest(http.MethodGet, "http://"+fa.httpAddr+"/.well-known/acme-challenge/token1", nil)
+		req.Host = "app.example"
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fa.t.Errorf("HTTP-01 request: %v", err)
+			reply(http.StatusBadRequest, map[string]string{"type": "urn:ietf:params:acme:error:connection"})
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !strings.HasPrefix(string(body), "token1.") {
+			fa.t.Errorf("unexpected key authorization: %s", string(body))
+			reply(http.StatusBadRequest, map[string]string{"type": "urn:ietf:params:acme:error:unauthorized"})
+			return
+		}
+		fa.status["authz"], fa.status["order"] = "valid", "ready"
+		challenge["status"] = "valid"
+		reply(http.StatusOK, challenge)
+	case "/finalize":
+		var req struct {
+			CSR string `json:"csr"`
+		}
+		if err := json.Unmarshal(fa.payload(r), &req); err != nil {
+			fa.t.Errorf("decode finalize: %v", err)
+		}
+		der, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil {
+			fa.t.Errorf("parse CSR: %v", err)
+			reply(http.StatusBadRequest, map[string]string{"type": "urn:ietf:params:acme:error:badCSR"})
+			return
+		}
+		der, err = x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: csr.DNSNames[0]},
+			DNSNames:     csr.DNSNames,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}, fa.caCert, csr.PublicKey, fa.caKey)
+		if err != nil {
+			fa.t.Errorf("create certificate: %v", err)
+		}
+		fa.certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+		fa.status["order"] = "valid"
+		order["status"], order["certificate"] = "valid", url+"/cert"
+		w.Header().Set("Location", url+"/order/1")
+		reply(http.StatusOK, order)
+	case "/cert":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		w.Write(fa.certPEM)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestRunACME(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	httpLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	httpAddr := httpLn.Addr().String()
+	httpLn.Close()
+
+	fa := newFakeACME(t, httpAddr)
+	dir := t.TempDir()
+	caRoot := filepath.Join(dir, "acme-ca.pem")
+	if err = os.WriteFile(caRoot, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: fa.srv.Certificate().Raw}), 0o600); err != nil {
+		t.Fatalf("write ca: %v", err)
+	}
+	domain := "app.example"
+	srv := New(Config{AccessLog: AccessLogDisabled, GracePeriod: time.Second, ACME: ACMEConfig{
+		Domains:      []string{domain},
+		CacheDir:     filepath.Join(dir, "cache"),
+		DirectoryURL: fa.srv.URL + "/dir",
+		CARoot:       caRoot,
+		HTTPListen:   httpAddr,
+	}})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- srv.WithListener(ln).Run(ctx)
+	}()
+	// HTTP-01 listener must be ready before certificate request
+	for i := 0; ; i++ {
+		conn, err := net.Dial("tcp", httpAddr)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if i == 50 {
+			t.Fatalf("HTTP-01 listener is not started: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(fa.caCert)
+	conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{ServerName: domain, RootCAs: roots, MinVersion: tls.VersionTLS12})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	names := conn.ConnectionState().PeerCertificates[0].DNSNames
+	conn.Close()
+	if !slices.Contains(names, domain) {
+		t.Fatalf("unexpected certificate names: %v", names)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "cache", domain)); err != nil {
+		t.Fatalf("certificate is not cached: %v", err)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+// unixClient returns HTTP client connected to unix socket.
+func unixClient(path string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func TestAddListener(t *testing.T) {
+	dir := t.TempDir()
+	adminSock, redirectSock := filepath.Join(dir, "admin.sock"), filepath.Join(dir, "http.sock")
+	// stale socket file is removed
+	stale, err := net.Listen("unix", adminSock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	admin := http.NewServeMux()
+	admin.HandleFunc("/admin", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("admin"))
+	})
+	header := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Listener", "admin")
+			next.ServeHTTP(w, r)
+		})
+	}
+	srv := New(Config{Listen: "127.0.0.1:0", AccessLog: AccessLogDisabled, GracePeriod: time.Second}).
+		AddListener("admin", ListenerConfig{Listen: UnixPrefix + adminSock, ReadTimeout: time.Second}, admin, header).
+		AddListener("http", ListenerConfig{Listen: UnixPrefix + redirectSock}, RedirectHTTPS("8443")).
+		AddListener("disabled", ListenerConfig{}, admin)
+	if len(srv.listeners) != 2 {
+		t.Fatalf("unexpected listeners: %d", len(srv.listeners))
+	}
+	srv.mux.HandleFunc("/admin", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("public"))
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := unixClient(adminSock).Get("http://admin/admin")
+	if err != nil {
+		t.Fatalf("GET admin: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "admin" || resp.Header.Get("X-Listener") != "admin" {
+		t.Fatalf("unexpected admin response: %s %v", string(body), resp.Header)
+	}
+
+	resp, err = unixClient(redirectSock).Get("http://app.example/path?q=1")
+	if err != nil {
+		t.Fatalf("GET redirect: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPermanentRedirect || resp.Header.Get("Location") != "https://app.example:8443/path?q=1" {
+		t.Fatalf("unexpected redirect: %d %s", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if _, err := os.Stat(adminSock); !os.IsNotExist(err) {
+		t.Fatalf("socket file must be removed on shutdown: %v", err)
+	}
+}
+
+func TestAddListenerError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	sock := filepath.Join(t.TempDir(), "app.sock")
+	srv := New(Config{Listen: UnixPrefix + sock, AccessLog: AccessLogDisabled}).
+		AddListener("admin", ListenerConfig{Listen: ln.Addr().String()}, http.NewServeMux())
+	if err = srv.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "listener admin") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Fatalf("main listener must be closed on error: %v", err)
+	}
+}
+
+func TestRunUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "app.sock")
+	srv := New(Config{Listen: UnixPrefix + sock, AccessLog: AccessLogDisabled, GracePeriod: time.Second})
+	srv.mux.HandleFunc("/hello", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := unixClient(sock).Get("http://app/hello")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "hello" {
+		t.Fatalf("unexpected response: %s", string(body))
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestRedirectHTTPS(t *testing.T) {
+	for port, want := range map[string]string{
+		"":     "https://app.example/a",
+		"443":  "https://app.example/a",
+		"8443": "https://app.example:8443/a",
+	} {
+		rec := httptest.NewRecorder()
+		RedirectHTTPS(port).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://app.example:8080/a", nil))
+		if got := rec.Header().Get("Location"); got != want {
+			t.Fatalf("port %q: unexpected location: %s", port, got)
+		}
+	}
+}
+
 // Helper to expose Config method (not exported). We use reflection.
 func (srv *Service) Config() Config {
 	return srv.config
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Client certificate authentication modes (TLSConfig.ClientAuth).
+const (
+	ClientAuthNone    = "none"    // client certificate is not requested
+	ClientAuthRequest = "request" // client certificate is requested but not required
+	ClientAuthRequire = "require" // client certificate is required but not verified
+	ClientAuthVerify  = "verify"  // client certificate is required and verified by ClientCA
+)
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                tls.NoClientCert,
+	ClientAuthNone:    tls.NoClientCert,
+	ClientAuthRequest: tls.RequestClientCert,
+	ClientAuthRequire: tls.RequireAnyClientCert,
+	ClientAuthVerify:  tls.RequireAndVerifyClientCert,
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ErrNoClientCA returned when client CA file does not contain certificates.
+var ErrNoClientCA = errors.New("no certificates found")
+
+// ErrClientCARequired returned when client certificates must be verified but client CA is not set.
+var ErrClientCARequired = errors.New("client_auth=verify requires client_ca")
+
+// ErrClientCAUnused returned when client CA is set but client certificates are not requested.
+var ErrClientCAUnused = errors.New("client_ca requires client_auth other than none")
+
+// ServerConfig returns tls.Config for HTTPS server.
+// Certificate is not loaded, GetCertificate must be set by caller.
+func (cfg TLSConfig) ServerConfig() (*tls.Config, error) {
+	rv := &tls.Config{} //nolint:gosec // MinVersion is set below
+	auth, ok := clientAuthTypes[cfg.ClientAuth]
+	if !ok {
+		return nil, fmt.Errorf("unknown client auth mode %q", cfg.ClientAuth)
+	}
+	rv.ClientAuth = auth
+	switch {
+	case auth == tls.RequireAndVerifyClientCert && cfg.ClientCA == "":
+		// иначе сертификаты клиентов проверяются по системным CA
+		return nil, ErrClientCARequired
+	case auth == tls.NoClientCert && cfg.ClientCA != "":
+		return nil, ErrClientCAUnused
+	}
+	if cfg.MinVersion != "" {
+		if rv.MinVersion, ok = tlsVersions[cfg.MinVersion]; !ok {
+			return nil, fmt.Errorf("unknown TLS version %q", cfg.MinVersion)
+		}
+	}
+	if len(cfg.CipherSuites) > 0 {
+		ids := map[string]uint16{}
+		for _, suite := range tls.CipherSuites() {
+			ids[suite.Name] = suite.ID
+		}
+		for _, name := range cfg.CipherSuites {
+			id, ok := ids[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+			}
+			rv.CipherSuites = append(rv.CipherSuites, id)
+		}
+	}
+	if cfg.ClientCA != "" {
+		data, err := os.ReadFile(cfg.ClientCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("client CA %s: %w", cfg.ClientCA, ErrNoClientCA)
+		}
+		rv.ClientCAs = pool
+	}
+	return rv, nil
+}
+
+type ctxKeyClientSubject struct{}
+
+// ClientSubject returns subject of verified client certificate (like "CN=client,O=Org")
+// or empty string.
+func ClientSubject(ctx context.Context) string {
+	rv, _ := ctx.Value(ctxKeyClientSubject{}).(string)
+	return rv
+}
+
+// verifiedSubject returns subject of verified client certificate.
+// Certificates which were not verified (client_auth=request or require) are ignored.
+func verifiedSubject(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.String()
+}
+
+// clientIdentityHandler adds subject of verified client certificate to request context.
+func clientIdentityHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subject := verifiedSubject(r); subject != "" {
+			r = r.WithContext(context.WithValue(r.Context(), ctxKeyClientSubject{}, subject))
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
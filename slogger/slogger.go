@@ -16,7 +16,7 @@ import (
 
 // Config holds package configuration.
 type Config struct {
-	Debug       bool   `long:"debug" description:"Show debug info"  env:"DEBUG"`
+	Debug       bool   `long:"debug" description:"Show debug info"  env:"DEBUG" reload:"true"`
 	Format      string `description:"Output format (default: '', means use text if DEBUG)" long:"format" env:"FORMAT" choice:"" choice:"text" choice:"json"` //lint:ignore SA5008 accepted as correct
 	TimeFormat  string `description:"Time format for text output" long:"time_format" env:"TIME_FORMAT" default:"2006-01-02 15:04:05.000"`
 	Destination string `description:"Log destination (default: '', means STDERR)" long:"dest" env:"DEST"`